@@ -0,0 +1,143 @@
+package gsms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/* ================================================================================
+ * 阿里大鱼短信发送记录查询（alibaba.aliqin.fc.sms.num.query）
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	alidayuQueryMethod = "alibaba.aliqin.fc.sms.num.query"
+)
+
+type (
+	AlidayuSendDetailsQuery struct {
+		Mobile      string    `form:"rec_num" json:"rec_num"`           //接收手机号（必填）
+		BizId       string    `form:"biz_id" json:"biz_id"`             //发送回执Id，即SmsResult.Model
+		QueryDate   time.Time `form:"query_date" json:"query_date"`     //发送日期，支持最近30天（必填）
+		PageSize    int       `form:"page_size" json:"page_size"`       //每页记录数，最大50，默认10
+		CurrentPage int       `form:"current_page" json:"current_page"` //当前页码，从1开始，默认1
+	}
+
+	AlidayuSendDetailsResult struct {
+		RequestId   string               `form:"request_id" json:"request_id"`
+		CurrentPage int64                `form:"current_page" json:"current_page"`
+		PageSize    int64                `form:"page_size" json:"page_size"`
+		TotalCount  int64                `form:"total_count" json:"total_count"`
+		TotalPage   int64                `form:"total_page" json:"total_page"`
+		Details     []*AlidayuSendDetail `form:"details" json:"details"`
+	}
+
+	AlidayuSendDetail struct {
+		Mobile       string           `form:"rec_num" json:"rec_num" xml:"rec_num"`                               //接收手机号
+		Status       AliyunSendStatus `form:"sms_status" json:"sms_status" xml:"sms_status"`                      //发送状态，取值与阿里云相同
+		ResultCode   string           `form:"result_code" json:"result_code" xml:"result_code"`                   //运营商状态码
+		TemplateCode string           `form:"sms_code" json:"sms_code" xml:"sms_code"`                            //模版码
+		Content      string           `form:"sms_content" json:"sms_content" xml:"sms_content"`                   //短信内容
+		SendDate     string           `form:"sms_send_time" json:"sms_send_time" xml:"sms_send_time"`             //发送时间，yyyy-MM-dd HH:mm:ss
+		ReceiveDate  string           `form:"sms_receiver_time" json:"sms_receiver_time" xml:"sms_receiver_time"` //接收时间，yyyy-MM-dd HH:mm:ss
+		Extend       string           `form:"extend" json:"extend" xml:"extend"`                                  //公共回传参数，即发送时的OutId
+	}
+
+	alidayuSendDetailsResponse struct {
+		RequestId   string                  `form:"request_id" json:"request_id" xml:"request_id"`
+		CurrentPage json.Number             `form:"current_page" json:"current_page" xml:"current_page"`
+		PageSize    json.Number             `form:"page_size" json:"page_size" xml:"page_size"`
+		TotalCount  json.Number             `form:"total_count" json:"total_count" xml:"total_count"`
+		TotalPage   json.Number             `form:"total_page" json:"total_page" xml:"total_page"`
+		Values      alidayuSendDetailValues `form:"values" json:"values" xml:"values"`
+	}
+
+	alidayuSendDetailValues struct {
+		Items []*AlidayuSendDetail `form:"fc_partner_sms_detail_dto" json:"fc_partner_sms_detail_dto" xml:"fc_partner_sms_detail_dto"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 查询短信发送记录和回执状态
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuSms) QuerySendDetails(ctx context.Context, query *AlidayuSendDetailsQuery) (*AlidayuSendDetailsResult, error) {
+	result := new(AlidayuSendDetailsResult)
+
+	if query == nil || len(query.Mobile) == 0 {
+		return result, ErrInvalidMobile
+	}
+
+	if query.QueryDate.IsZero() {
+		return result, ErrInvalidArgument
+	}
+
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	currentPage := query.CurrentPage
+	if currentPage <= 0 {
+		currentPage = 1
+	}
+
+	//业务参数
+	params := make(map[string]string, 0)
+	params["rec_num"] = query.Mobile
+	params["biz_id"] = query.BizId
+	params["query_date"] = query.QueryDate.In(chinaLocation).Format("20060102")
+	params["page_size"] = fmt.Sprintf("%d", pageSize)
+	params["current_page"] = fmt.Sprintf("%d", currentPage)
+
+	//发送请求
+	var response alidayuSendDetailsResponse
+	if err := s.client.Call(ctx, alidayuQueryMethod, params, &response); err != nil {
+		return result, err
+	}
+
+	result.RequestId = response.RequestId
+	result.CurrentPage, _ = response.CurrentPage.Int64()
+	result.PageSize, _ = response.PageSize.Int64()
+	result.TotalCount, _ = response.TotalCount.Int64()
+	result.TotalPage, _ = response.TotalPage.Int64()
+	result.Details = response.Values.Items
+
+	return result, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 记录列表，simplify=true时为数组，否则包裹在fc_partner_sms_detail_dto中
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (v *alidayuSendDetailValues) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, &v.Items)
+	}
+
+	type values alidayuSendDetailValues
+	return json.Unmarshal(data, (*values)(v))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否已送达
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (d *AlidayuSendDetail) IsDelivered() bool {
+	return d.Status == AliyunSendStatusSuccess
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送时间
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (d *AlidayuSendDetail) SendTime() time.Time {
+	return parseChinaTime(d.SendDate)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 接收时间，未送达时为零值
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (d *AlidayuSendDetail) ReceiveTime() time.Time {
+	return parseChinaTime(d.ReceiveDate)
+}
@@ -0,0 +1,99 @@
+package gsms
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testAlidayuDetail = `{"extend":"123","rec_num":"13800000000","result_code":"DELIVRD","sms_code":"SMS_1","sms_content":"验证码1234","sms_receiver_time":"2016-01-01 12:00:05","sms_send_time":"2016-01-01 12:00:00","sms_status":3}`
+
+func TestAlidayuQuerySendDetails(t *testing.T) {
+	cases := []struct {
+		name     string
+		simplify bool
+		body     string
+	}{
+		{
+			"simplify array", true,
+			`{"current_page":2,"page_size":20,"total_count":21,"total_page":2,"values":[` + testAlidayuDetail + `],"request_id":"r1"}`,
+		},
+		{
+			"wrapped object", false,
+			`{"alibaba_aliqin_fc_sms_num_query_response":{"current_page":2,"page_size":20,"total_count":21,"total_page":2,` +
+				`"values":{"fc_partner_sms_detail_dto":[` + testAlidayuDetail + `]},"request_id":"r1"}}`,
+		},
+	}
+
+	for _, c := range cases {
+		var form url.Values
+		transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			form, _ = url.ParseQuery(string(body))
+			return newTestResponse(c.body), nil
+		})
+
+		sms := NewAlidayunSms("key", "secret", "sign", WithTransport(transport))
+		sms.(*alidayuSms).client.SetSimplify(c.simplify)
+
+		result, err := sms.QuerySendDetails(context.Background(), &AlidayuSendDetailsQuery{
+			Mobile:      "13800000000",
+			BizId:       "biz-1",
+			QueryDate:   time.Date(2016, 1, 1, 20, 0, 0, 0, time.UTC),
+			PageSize:    20,
+			CurrentPage: 2,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		if form.Get("method") != alidayuQueryMethod || form.Get("rec_num") != "13800000000" || form.Get("biz_id") != "biz-1" ||
+			form.Get("query_date") != "20160102" || form.Get("page_size") != "20" || form.Get("current_page") != "2" {
+			t.Errorf("%s: got params %v", c.name, form)
+		}
+
+		if result.RequestId != "r1" || result.CurrentPage != 2 || result.PageSize != 20 || result.TotalCount != 21 || result.TotalPage != 2 || len(result.Details) != 1 {
+			t.Fatalf("%s: got %#v", c.name, result)
+		}
+
+		detail := result.Details[0]
+		if detail.Mobile != "13800000000" || !detail.IsDelivered() || detail.Extend != "123" || detail.ResultCode != "DELIVRD" ||
+			!detail.SendTime().Equal(time.Date(2016, 1, 1, 12, 0, 0, 0, chinaLocation)) {
+			t.Errorf("%s: got %#v", c.name, detail)
+		}
+	}
+}
+
+func TestAlidayuQuerySendDetailsPaging(t *testing.T) {
+	var form url.Values
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		return newTestResponse(`{"current_page":1,"page_size":10,"total_count":0,"total_page":0,"values":[],"request_id":"r1"}`), nil
+	})
+
+	sms := NewAlidayunSms("key", "secret", "sign", WithTransport(transport))
+
+	result, err := sms.QuerySendDetails(context.Background(), &AlidayuSendDetailsQuery{
+		Mobile:    "13800000000",
+		QueryDate: time.Date(2016, 1, 1, 0, 0, 0, 0, chinaLocation),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//未设置分页时默认第1页，每页10条
+	if form.Get("page_size") != "10" || form.Get("current_page") != "1" || len(form.Get("biz_id")) > 0 {
+		t.Fatalf("got params %v", form)
+	}
+	if result.TotalCount != 0 || len(result.Details) != 0 {
+		t.Fatalf("got %#v", result)
+	}
+
+	if _, err := sms.QuerySendDetails(context.Background(), &AlidayuSendDetailsQuery{Mobile: "13800000000"}); err != ErrInvalidArgument {
+		t.Fatalf("got %v, want ErrInvalidArgument", err)
+	}
+}
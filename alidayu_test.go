@@ -0,0 +1,98 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAlidayuSendValidationReturnsResult(t *testing.T) {
+	sms := NewAlidayunSms("key", "secret", "sign")
+
+	message := NewMessage("13800000000")
+	message.OutId = "out-1"
+	result, err := sms.SendMessage(context.Background(), message)
+	if !errors.Is(err, ErrTemplateMissing) {
+		t.Fatalf("got %v, want ErrTemplateMissing", err)
+	}
+	if result == nil || result.OutId != "out-1" {
+		t.Fatalf("got result %#v", result)
+	}
+}
+
+func TestAlidayuConcurrentSend(t *testing.T) {
+	sms := NewAlidayunSms("key", "secret", "sign")
+
+	testConcurrentSend(t, sms, "", func(r *http.Request, body []byte) sentMessage {
+		form, _ := url.ParseQuery(string(body))
+		return sentMessage{
+			Mobile:       form.Get("rec_num"),
+			TemplateCode: form.Get("sms_template_code"),
+			SignName:     form.Get("sms_free_sign_name"),
+			Param:        templateParamCode(form.Get("sms_param")),
+			OutId:        form.Get("extend"),
+		}
+	}, func(sent sentMessage) string {
+		return fmt.Sprintf(`{"result":{"err_code":0,"model":"m-%s","success":true},"request_id":"r-%s"}`, sent.OutId, sent.OutId)
+	}, false, false)
+}
+
+func TestAlidayuXmlResponses(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		code  string
+		model string
+		err   error
+	}{
+		{
+			"success",
+			`<?xml version="1.0" encoding="utf-8" ?><alibaba_aliqin_fc_sms_num_send_response><result><err_code>0</err_code><model>134523^4351232</model><success>true</success></result><request_id>r1</request_id></alibaba_aliqin_fc_sms_num_send_response>`,
+			"0", "134523^4351232", nil,
+		},
+		{
+			"error_response root",
+			`<?xml version="1.0" encoding="utf-8" ?><error_response><code>15</code><msg>Remote service error</msg><sub_code>isv.MOBILE_NUMBER_ILLEGAL</sub_code><sub_msg>号码格式错误</sub_msg><request_id>r1</request_id></error_response>`,
+			"isv.MOBILE_NUMBER_ILLEGAL", "", ErrInvalidMobile,
+		},
+	}
+
+	for _, c := range cases {
+		for _, simplify := range []bool{true, false} {
+			var format string
+			transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				body, _ := ioutil.ReadAll(r.Body)
+				form, _ := url.ParseQuery(string(body))
+				format = form.Get("format")
+
+				return newXmlTestResponse(c.body), nil
+			})
+
+			sms := NewAlidayunSms("key", "secret", "sign", WithFormat("xml"), WithTransport(transport))
+			sms.(*alidayuSms).client.SetSimplify(simplify)
+			sms.SetTemplateCode("SMS_1")
+			sms.SetTemplateParam(NewSmsTemplateParams("code", "1234"))
+
+			result, err := sms.SendMessage(context.Background(), NewMessage("13800000000"))
+			if !errors.Is(err, c.err) {
+				t.Errorf("%s simplify %v: got %v, want %v", c.name, simplify, err, c.err)
+			}
+			if format != "xml" || result.Code != c.code || result.Model != c.model || result.IsSuccess != (c.err == nil) {
+				t.Errorf("%s simplify %v: got %s %#v", c.name, simplify, format, result)
+			}
+
+			var smsErr *SmsError
+			if c.err != nil && (!errors.As(err, &smsErr) || smsErr.Code != "isv.MOBILE_NUMBER_ILLEGAL" || smsErr.RequestId != "r1") {
+				t.Errorf("%s simplify %v: got %#v", c.name, simplify, err)
+			}
+		}
+	}
+}
+
+func TestAlidayuCancelSend(t *testing.T) {
+	testCancelSend(t, NewAlidayunSms("key", "secret", "sign"), "")
+}
@@ -0,0 +1,160 @@
+package gsms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+/* ================================================================================
+ * 阿里大鱼语音通知和语音验证码
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	alidayuTtsMethod   = "alibaba.aliqin.fc.tts.num.singlecall"   //文本转语音通知
+	alidayuVoiceMethod = "alibaba.aliqin.fc.voice.num.singlecall" //语音文件通知
+)
+
+type (
+	alidayuVoice struct {
+		TtsCode    string `form:"tts_code" json:"tts_code"`
+		ShowNumber string `form:"called_show_num" json:"called_show_num"`
+		client     *topClient
+		mu         sync.RWMutex
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建阿里大鱼语音提供者
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAlidayunVoice(appKey, appSecret, showNumber string, opts ...Option) VoiceProvider {
+	voice := new(alidayuVoice)
+	voice.ShowNumber = showNumber
+	voice.client = newTopClient(appKey, appSecret, newOptions(opts))
+
+	return voice
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 呼叫手机号，使用默认的文本转语音模版
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuVoice) Call(mobile string) (*SmsResult, error) {
+	return s.CallContext(context.Background(), mobile)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 呼叫手机号，ctx取消或超时会中断请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuVoice) CallContext(ctx context.Context, mobile string) (*SmsResult, error) {
+	return s.CallMessage(ctx, &VoiceMessage{Mobile: mobile})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发起语音呼叫，可并发调用
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuVoice) CallMessage(ctx context.Context, message *VoiceMessage) (*SmsResult, error) {
+	result := new(SmsResult)
+
+	if message == nil || len(message.Mobile) == 0 {
+		return result, ErrInvalidMobile
+	}
+
+	//合并默认值
+	message = s.resolve(message)
+	result.OutId = message.OutId
+
+	if len(message.ShowNumber) == 0 {
+		return result, fmt.Errorf("%w: 被叫号显不能为空", ErrInvalidArgument)
+	}
+
+	//业务参数
+	method := alidayuVoiceMethod
+	params := make(map[string]string, 0)
+	params["called_num"] = message.Mobile
+	params["called_show_num"] = message.ShowNumber
+	params["extend"] = message.OutId
+
+	if len(message.VoiceCode) > 0 {
+		params["voice_code"] = message.VoiceCode
+	} else if len(message.TtsCode) > 0 {
+		method = alidayuTtsMethod
+		params["tts_code"] = message.TtsCode
+
+		if message.TtsParam != nil {
+			ttsParam, err := message.TtsParam.Json()
+			if err != nil {
+				return result, fmt.Errorf("%w: %v", ErrTemplateParamInvalid, err)
+			}
+			params["tts_param"] = ttsParam
+		}
+	} else {
+		return result, ErrTemplateMissing
+	}
+
+	//发送请求
+	response := new(AlidayuSmsSendSuccessResponse)
+	if err := s.client.Call(ctx, method, params, response); err != nil {
+		result.setError(err)
+		return result, err
+	}
+
+	result.Code = fmt.Sprintf("%d", response.Result.Code)
+	result.Message = response.Result.Message
+	result.Model = response.Result.Model
+	result.RequestId = response.RequestId
+	result.IsSuccess = response.Result.Success
+
+	if !result.IsSuccess {
+		return result, newSmsError("alidayu", alidayuErrorCodes, result.Code, result.Message, result.RequestId)
+	}
+
+	return result, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置默认文本转语音模版码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuVoice) SetTtsCode(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TtsCode = code
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置默认被叫号显
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuVoice) SetShowNumber(showNumber string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ShowNumber = showNumber
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置发送网关
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuVoice) SetGeteway(geteway string) {
+	s.client.SetGeteway(geteway)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 合并默认值，返回新的消息副本
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *alidayuVoice) resolve(message *VoiceMessage) *VoiceMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	voiceMessage := *message
+	if len(voiceMessage.ShowNumber) == 0 {
+		voiceMessage.ShowNumber = s.ShowNumber
+	}
+
+	if len(voiceMessage.VoiceCode) == 0 && len(voiceMessage.TtsCode) == 0 {
+		voiceMessage.TtsCode = s.TtsCode
+	}
+
+	return &voiceMessage
+}
@@ -0,0 +1,92 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// 返回固定响应的阿里大鱼语音提供者，记录最后一次请求的参数
+func newAlidayuVoiceTest(body string, form *url.Values) VoiceProvider {
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		data, _ := ioutil.ReadAll(r.Body)
+		*form, _ = url.ParseQuery(string(data))
+		return newTestResponse(body), nil
+	})
+
+	return NewAlidayunVoice("key", "secret", "4001112222", WithTransport(transport))
+}
+
+func TestAlidayuVoiceTtsCall(t *testing.T) {
+	var form url.Values
+	voice := newAlidayuVoiceTest(`{"result":{"err_code":"0","model":"call-1","success":true},"request_id":"r1"}`, &form)
+	voice.SetTtsCode("TTS_1")
+
+	result, err := voice.CallMessage(context.Background(), &VoiceMessage{
+		Mobile:   "13800000000",
+		TtsParam: NewSmsTemplateParams("code", "1234", "product", "gsms"),
+		OutId:    "out-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Get("method") != alidayuTtsMethod || form.Get("called_num") != "13800000000" || form.Get("called_show_num") != "4001112222" ||
+		form.Get("tts_code") != "TTS_1" || form.Get("tts_param") != `{"code":"1234","product":"gsms"}` || form.Get("extend") != "out-1" ||
+		len(form.Get("voice_code")) > 0 {
+		t.Fatalf("got params %v", form)
+	}
+
+	if !result.IsSuccess || result.Model != "call-1" || result.RequestId != "r1" || result.OutId != "out-1" {
+		t.Fatalf("got %#v", result)
+	}
+}
+
+func TestAlidayuVoiceFileCall(t *testing.T) {
+	var form url.Values
+	voice := newAlidayuVoiceTest(`{"result":{"err_code":"0","model":"call-2","success":true},"request_id":"r2"}`, &form)
+	voice.SetTtsCode("TTS_1")
+
+	//语音文件优先于默认的文本转语音模版
+	result, err := voice.CallMessage(context.Background(), &VoiceMessage{
+		Mobile:     "13800000000",
+		ShowNumber: "4003334444",
+		VoiceCode:  "voice-1.wav",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Get("method") != alidayuVoiceMethod || form.Get("voice_code") != "voice-1.wav" || form.Get("called_show_num") != "4003334444" ||
+		len(form.Get("tts_code")) > 0 || len(form.Get("tts_param")) > 0 {
+		t.Fatalf("got params %v", form)
+	}
+
+	if !result.IsSuccess || result.Model != "call-2" || result.RequestId != "r2" {
+		t.Fatalf("got %#v", result)
+	}
+}
+
+func TestAlidayuVoiceErrors(t *testing.T) {
+	var form url.Values
+	voice := newAlidayuVoiceTest(`{"error_response":{"code":15,"msg":"Remote service error","sub_code":"isv.MOBILE_NUMBER_ILLEGAL","sub_msg":"号码格式错误","request_id":"r3"}}`, &form)
+
+	//未设置模版和语音文件时不发送请求
+	if _, err := voice.Call("13800000000"); !errors.Is(err, ErrTemplateMissing) || form != nil {
+		t.Fatalf("got %v %v", err, form)
+	}
+
+	voice.SetTtsCode("TTS_1")
+	result, err := voice.Call("13800000000")
+	if !errors.Is(err, ErrInvalidMobile) || result.Code != "isv.MOBILE_NUMBER_ILLEGAL" || result.RequestId != "r3" || result.IsSuccess {
+		t.Fatalf("got %v %#v", err, result)
+	}
+
+	voice.SetShowNumber("")
+	if _, err := voice.Call("13800000000"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("got %v, want ErrInvalidArgument", err)
+	}
+}
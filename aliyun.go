@@ -1,12 +1,14 @@
 package gsms
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
 )
 
 import (
@@ -21,55 +23,69 @@ import (
  * ================================================================================ */
 type (
 	AliyunSmsSendResultResponse struct {
-		Code      string `form:"Code" json:"Code"`
-		Message   string `form:"Message" json:"Message"`
-		RequestId string `form:"RequestId" json:"RequestId"`
-		BizId     string `form:"BizId" json:"BizId"`
+		Code      string `form:"Code" json:"Code" xml:"Code"`
+		Message   string `form:"Message" json:"Message" xml:"Message"`
+		RequestId string `form:"RequestId" json:"RequestId" xml:"RequestId"`
+		BizId     string `form:"BizId" json:"BizId" xml:"BizId"`
+	}
+
+	AliyunSmsProvider interface {
+		SmsProvider
+		SendBatch(ctx context.Context, batchMessage *AliyunBatchMessage) (*AliyunBatchResult, error)
+		QuerySendDetails(ctx context.Context, query *AliyunSendDetailsQuery) (*AliyunSendDetailsResult, error)
 	}
 
 	aliyunSms struct {
-		Geteway          string `form:"Geteway" json:"Geteway"`                   //网关
-		Action           string `form:"Action" json:"Action"`                     //操作接口名，系统规定参数，取值：SingleSendSms
-		SignName         string `form:"SignName" json:"SignName"`                 //短信签名
-		TemplateCode     string `form:"TemplateCode" json:"TemplateCode"`         //短信模板的模板CODE（状态必须是验证通过）
-		PhoneNumbers     string `form:"PhoneNumbers" json:"PhoneNumbers"`         //目标手机号，多个手机号可以逗号分隔
-		ParamString      string `form:"ParamString" json:"ParamString"`           //短信模板中的变量；数字需要转换为字符串
-		RegionId         string `form:"RegionId" json:"RegionId"`                 //区域ID
-		AccessKeyId      string `form:"AccessKeyId" json:"AccessKeyId"`           //access id
-		AccessKeySecret  string `form:"AccessKeySecret" json:"AccessKeySecret"`   //私匙
-		Signature        string `form:"Signature" json:"Signature"`               //签名结果串
-		SignatureNonce   string `form:"SignatureNonce" json:"SignatureNonce"`     //唯一随机数，用于防止网络重放攻击。用户在不同请求间要使用不同的随机数值
-		SignatureMethod  string `form:"SignatureMethod" json:"SignatureMethod"`   //签名方式，目前支持HMAC-SHA1
-		SignatureVersion string `form:"SignatureVersion" json:"SignatureVersion"` //签名算法版本，目前版本是1.0
-		Format           string `form:"Format" json:"Format"`                     //返回值的类型，支持JSON与XML。默认为XML
-		Timestamp        string `form:"Timestamp" json:"Timestamp"`               //请求的时间戳。日期格式按照ISO8601标准表示，并需要使用UTC时间。格式为YYYY-MM-DDThh:mm:ssZ 例如，2015-11-23T04:00:00Z（为北京时间2015年11月23日12点0分0秒）
-		Version          string `form:"Version" json:"Version"`                   //API版本号，为日期形式：YYYY-MM-DD，本版本对应为2016-09-27
+		Geteway          string                    `form:"Geteway" json:"Geteway"`                   //网关
+		Action           string                    `form:"Action" json:"Action"`                     //操作接口名，系统规定参数，取值：SingleSendSms
+		SignName         string                    `form:"SignName" json:"SignName"`                 //默认短信签名
+		TemplateCode     string                    `form:"TemplateCode" json:"TemplateCode"`         //默认短信模板的模板CODE（状态必须是验证通过）
+		ParamString      string                    `form:"ParamString" json:"ParamString"`           //默认短信模板中的变量；数字需要转换为字符串
+		TemplateParam    SmsTemplateParams         `form:"TemplateParam" json:"TemplateParam"`       //默认模版参数，优先于ParamString
+		RegionId         string                    `form:"RegionId" json:"RegionId"`                 //区域ID
+		Credentials      AliyunCredentialsProvider `form:"-" json:"-"`                               //访问凭证（access id、私匙、STS令牌）
+		SignatureMethod  string                    `form:"SignatureMethod" json:"SignatureMethod"`   //签名方式，目前支持HMAC-SHA1
+		SignatureVersion string                    `form:"SignatureVersion" json:"SignatureVersion"` //签名算法版本，目前版本是1.0
+		Format           string                    `form:"Format" json:"Format"`                     //返回值的类型，支持JSON与XML，默认为JSON，可通过WithFormat设置
+		Version          string                    `form:"Version" json:"Version"`                   //API版本号，为日期形式：YYYY-MM-DD，本版本对应为2016-09-27
+		options          options
+		mu               sync.RWMutex //保护默认值
 	}
 )
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 创建阿里云短信提供者
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func NewAliyunSms(accessKeyId, accessKeySecret, regionId, signName string) SmsProvider {
+func NewAliyunSms(accessKeyId, accessKeySecret, regionId, signName string, opts ...Option) AliyunSmsProvider {
+	return newAliyunSms(accessKeyId, accessKeySecret, regionId, signName, opts...)
+}
+
+func newAliyunSms(accessKeyId, accessKeySecret, regionId, signName string, opts ...Option) *aliyunSms {
 	yunSms := new(aliyunSms)
 	yunSms.Geteway = "https://dysmsapi.aliyuncs.com"
 	yunSms.Action = "SendSms"
 	yunSms.SignName = signName
-	yunSms.AccessKeyId = accessKeyId
-	yunSms.AccessKeySecret = accessKeySecret
 
 	if len(regionId) == 0 {
 		regionId = "cn-hangzhou"
 	}
 	yunSms.RegionId = regionId
 
-	yunSms.SignatureNonce = glib.Guid()
 	yunSms.SignatureMethod = "HMAC-SHA1"
 	yunSms.SignatureVersion = "1.0"
 	yunSms.Format = "JSON"
-	nowDate := glib.AddMinutesForCurrent(-8 * 60)                         //相差8个时区
-	yunSms.Timestamp = glib.TimeToString(nowDate, "2006-01-02T15:04:05Z") //yyyy-MM-dd’T’HH:mm:ss’Z’
 	yunSms.Version = "2017-05-25"
+	yunSms.options = newOptions(opts)
+
+	if len(yunSms.options.format) > 0 {
+		yunSms.Format = strings.ToUpper(yunSms.options.format)
+	}
+
+	//未设置凭证提供者时使用静态access key
+	yunSms.Credentials = yunSms.options.aliyunCredentials
+	if yunSms.Credentials == nil {
+		yunSms.Credentials = NewAliyunStaticCredentials(accessKeyId, accessKeySecret, "")
+	}
 
 	return yunSms
 }
@@ -78,53 +94,83 @@ func NewAliyunSms(accessKeyId, accessKeySecret, regionId, signName string) SmsPr
  * 设置发送网关
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *aliyunSms) SetGeteway(geteway string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.Geteway = geteway
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 发送手机信息
+ * 发送手机信息（多个手机号用逗号分隔）
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *aliyunSms) Send(mobiles string) (*SmsResult, error) {
+	return s.SendContext(context.Background(), mobiles)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送手机信息，ctx取消或超时会中断请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) SendContext(ctx context.Context, mobiles string) (*SmsResult, error) {
+	return s.SendMessage(ctx, NewMessage(splitMobiles(mobiles)...))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送短信消息，可并发调用
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) SendMessage(ctx context.Context, message *Message) (*SmsResult, error) {
 	result := new(SmsResult)
 	result.IsSuccess = false
 
-	if len(mobiles) == 0 {
-		return result, errors.New("argument error")
+	if message == nil || len(message.Mobiles) == 0 {
+		return result, ErrInvalidMobile
 	}
 
-	if len(s.TemplateCode) == 0 || len(s.ParamString) == 0 || len(s.SignName) == 0 {
-		return result, errors.New("参数不正确")
-	}
+	//合并默认值
+	message = s.resolve(message)
+	result.OutId = message.OutId
 
-	//接收手机号码
-	s.PhoneNumbers = mobiles
-
-	//签名
-	s.Sign()
+	//国内号码和国际/港澳台号码（国际区号+号码）
+	mobiles := make([]string, 0, len(message.Mobiles))
+	for _, mobile := range message.Mobiles {
+		mobile, err := aliyunMobile(mobile)
+		if err != nil {
+			return result, err
+		}
+		mobiles = append(mobiles, mobile)
+	}
 
-	//获取参数字符串，然后附加签名字符串
-	//params := s.GetParamString(false) + "&Signature=" + s.Signature
-	params := s.GetParamString(true) + "&Signature=" + s.Signature
+	paramString := s.paramString(message)
+	if err := validateMessage(message, paramString); err != nil {
+		return result, err
+	}
 
-	//发送Http请求
-	if response, err := glib.HttpPost(s.Geteway, params); err != nil {
+	//业务参数
+	params := make(map[string]string, 0)
+	params["PhoneNumbers"] = strings.Join(mobiles, ",")
+	params["SignName"] = message.SignName
+	params["TemplateCode"] = message.TemplateCode
+	params["TemplateParam"] = paramString
+	params["OutId"] = message.OutId
+	params["SmsUpExtendCode"] = message.ExtendCode
+
+	//发送请求
+	var resultResponse AliyunSmsSendResultResponse
+	if err := s.call(ctx, s.Action, params, &resultResponse); err != nil {
 		log.Printf("aliyun sms send err %v", err)
 		return result, err
-	} else {
-		//解析发送成功数据
-		var resultResponse AliyunSmsSendResultResponse
-		glib.FromJson(response, &resultResponse)
+	}
 
-		result.Code = resultResponse.Code
-		result.Message = resultResponse.Message
-		result.RequestId = resultResponse.RequestId
-		result.Model = resultResponse.BizId
+	result.Code = resultResponse.Code
+	result.Message = resultResponse.Message
+	result.RequestId = resultResponse.RequestId
+	result.Model = resultResponse.BizId
 
-		if result.Code == "OK" {
-			result.IsSuccess = true
-		}
+	if err := aliyunResponseError(resultResponse.Code, resultResponse.Message, resultResponse.RequestId); err != nil {
+		return result, err
 	}
 
+	result.IsSuccess = true
+
 	return result, nil
 }
 
@@ -132,38 +178,48 @@ func (s *aliyunSms) Send(mobiles string) (*SmsResult, error) {
  * 设置模版码
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *aliyunSms) SetTemplateCode(code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.TemplateCode = code
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 设置模版参数
+ * 设置模版参数（序列化为Json对象）
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *aliyunSms) SetTemplateParam(templateParam SmsTemplateParam) {
-	if jsonString, err := glib.ToJson(templateParam); err == nil {
-		s.ParamString = jsonString
-	}
+func (s *aliyunSms) SetTemplateParam(templateParam SmsTemplateParamer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateParam = toTemplateParams(templateParam)
+	s.ParamString = ""
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 设置模版参数字符串
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *aliyunSms) SetTemplateString(templateString string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.ParamString = templateString
+	s.TemplateParam = nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 设置签名字符串
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *aliyunSms) SetSignName(signName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.SignName = signName
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 待签名字符串
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *aliyunSms) GetParamString(isEncoding bool) string {
-	dict := s.toDict()
-
+func (s *aliyunSms) GetParamString(dict map[string]string, isEncoding bool) string {
 	var keys []string = make([]string, 0)
 	var params []string = make([]string, 0)
 
@@ -194,21 +250,28 @@ func (s *aliyunSms) GetParamString(isEncoding bool) string {
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 签名字符串
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *aliyunSms) Sign() {
+func (s *aliyunSms) Sign(dict map[string]string, accessKeySecret string) string {
+	return s.signString(http.MethodPost, dict, accessKeySecret)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按指定请求方法签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) signString(method string, dict map[string]string, accessKeySecret string) string {
 	//获取待签名字符串
-	waitSignString := s.GetParamString(true)
+	waitSignString := s.GetParamString(dict, true)
 
 	//链接和编码参数
-	stringToSign := fmt.Sprintf("%s&%s&%s", "POST", s.PercentEncode("/"), s.PercentEncode(waitSignString))
+	stringToSign := fmt.Sprintf("%s&%s&%s", method, s.PercentEncode("/"), s.PercentEncode(waitSignString))
 
 	//秘匙
-	secret := s.AccessKeySecret + "&"
+	secret := accessKeySecret + "&"
 
 	//hmac sha1签名
 	sign := glib.HmacSha1(stringToSign, secret, false)
 
 	//base64编码
-	s.Signature = s.PercentEncode(glib.ToBase64(sign))
+	return s.PercentEncode(glib.ToBase64(sign))
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
@@ -224,23 +287,160 @@ func (s *aliyunSms) PercentEncode(str string) string {
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 转成有序字典
+ * 调用接口，签名请求参数并解析响应数据
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *aliyunSms) toDict() map[string]string {
-	params := make(map[string]string, 0)
-	params["Action"] = s.Action
-	params["PhoneNumbers"] = s.PhoneNumbers
-	params["SignName"] = s.SignName
-	params["TemplateCode"] = s.TemplateCode
-	params["TemplateParam"] = s.ParamString
-	params["AccessKeyId"] = s.AccessKeyId
-	params["RegionId"] = s.RegionId
-	params["SignatureNonce"] = s.SignatureNonce
-	params["SignatureMethod"] = s.SignatureMethod
-	params["SignatureVersion"] = s.SignatureVersion
-	params["Format"] = s.Format
-	params["Timestamp"] = s.Timestamp
-	params["Version"] = s.Version
-
-	return params
+func (s *aliyunSms) call(ctx context.Context, action string, params map[string]string, response interface{}) error {
+	//每次请求获取凭证，STS临时凭证过期前自动刷新
+	credentials, err := s.Credentials.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	var httpResponse *httpResponse
+	if s.options.aliyunSignatureVersion == AliyunSignatureV3 {
+		httpResponse, err = s.callV3(ctx, credentials, action, params)
+	} else {
+		httpResponse, err = s.callV1(ctx, credentials, action, params)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	//V3签名的请求不传Format参数，由Content-Type决定
+	return httpResponse.decode(s.Format, response)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 使用HMAC-SHA1签名（SignatureVersion 1.0）发送请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) callV1(ctx context.Context, credentials *AliyunCredentials, action string, params map[string]string) (*httpResponse, error) {
+	//请求参数
+	dict := s.toDict(credentials, action, params)
+
+	//签名
+	signature := s.Sign(dict, credentials.AccessKeySecret)
+
+	//获取参数字符串，然后附加签名字符串
+	requestString := s.GetParamString(dict, true) + "&Signature=" + signature
+
+	//发送Http请求
+	return s.options.httpPost(ctx, s.geteway(), requestString)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取网关
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) geteway() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Geteway
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 合并消息默认值
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) resolve(message *Message) *Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return message.merge(Message{
+		TemplateCode:   s.TemplateCode,
+		TemplateParam:  s.TemplateParam,
+		TemplateString: s.ParamString,
+		SignName:       s.SignName,
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 模版参数字符串
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) paramString(message *Message) string {
+	if message.TemplateParam != nil {
+		if jsonString, err := message.TemplateParam.Json(); err == nil {
+			return jsonString
+		}
+	}
+
+	return message.TemplateString
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转成有序字典（公共参数加业务参数）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) toDict(credentials *AliyunCredentials, action string, params map[string]string) map[string]string {
+	dict := make(map[string]string, 0)
+	for key, value := range params {
+		dict[key] = value
+	}
+
+	dict["Action"] = action
+	dict["AccessKeyId"] = credentials.AccessKeyId
+	dict["SecurityToken"] = credentials.SecurityToken //STS临时凭证令牌，为空时不参与签名
+	dict["RegionId"] = s.RegionId
+	dict["SignatureNonce"] = s.options.nonce() //唯一随机数，用于防止网络重放攻击，每次请求重新生成
+	dict["SignatureMethod"] = s.SignatureMethod
+	dict["SignatureVersion"] = s.SignatureVersion
+	dict["Format"] = s.Format
+	dict["Timestamp"] = s.options.now().UTC().Format("2006-01-02T15:04:05Z") //ISO8601标准的UTC时间，每次请求重新生成
+	dict["Version"] = s.Version
+
+	return dict
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为阿里云手机号格式
+ * 国内号码为11位手机号（去掉+86/0086/86前缀），国际/港澳台号码为国际区号+号码，例如：85200000000
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func aliyunMobile(mobile string) (string, error) {
+	mobile, _, err := mobileDigits(mobile)
+	if err != nil {
+		return "", err
+	}
+
+	if len(mobile) == 13 && strings.HasPrefix(mobile, "86") {
+		mobile = mobile[2:]
+	}
+
+	return mobile, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 去掉手机号中的空格、短横线、括号和+/00前缀，返回数字和是否带国际前缀
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func mobileDigits(mobile string) (string, bool, error) {
+	mobile = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(mobile)
+
+	isInternational := true
+	if strings.HasPrefix(mobile, "+") {
+		mobile = mobile[1:]
+	} else if strings.HasPrefix(mobile, "00") {
+		mobile = mobile[2:]
+	} else {
+		isInternational = false
+	}
+
+	if len(mobile) == 0 {
+		return "", false, ErrInvalidMobile
+	}
+
+	for _, c := range mobile {
+		if c < '0' || c > '9' {
+			return "", false, fmt.Errorf("%w: %s", ErrInvalidMobile, mobile)
+		}
+	}
+
+	return mobile, isInternational, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 响应码不为OK时返回网关错误
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func aliyunResponseError(code, message, requestId string) error {
+	if code == "OK" {
+		return nil
+	}
+
+	return newSmsError("aliyun", aliyunErrorCodes, code, message, requestId)
 }
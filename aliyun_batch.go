@@ -0,0 +1,214 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+)
+
+/* ================================================================================
+ * 阿里云批量短信发送（SendBatchSms）
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	AliyunBatchSize = 100 //SendBatchSms单次请求最多支持的手机号数量
+)
+
+type (
+	/*
+	 * 批量短信，每个接收者可以使用不同的签名和模版参数
+	 * 未设置的模版码、签名、模版参数使用提供者的默认值
+	 */
+	AliyunBatchMessage struct {
+		TemplateCode string            `form:"template_code" json:"template_code"` //模版码
+		OutId        string            `form:"out_id" json:"out_id"`               //调用方流水号，回执中原样返回
+		Items        []AliyunBatchItem `form:"items" json:"items"`                 //接收者
+	}
+
+	AliyunBatchItem struct {
+		Mobile        string            `form:"mobile" json:"mobile"`                 //接收手机号
+		SignName      string            `form:"sign_name" json:"sign_name"`           //短信签名
+		TemplateParam SmsTemplateParams `form:"template_param" json:"template_param"` //模版参数
+		ExtendCode    string            `form:"extend_code" json:"extend_code"`       //上行短信扩展码
+	}
+
+	/*
+	 * 批量发送结果，Items与AliyunBatchMessage.Items一一对应
+	 */
+	AliyunBatchResult struct {
+		Items     []*AliyunBatchItemResult `form:"items" json:"items"`
+		IsSuccess bool                     `form:"is_success" json:"is_success"`
+	}
+
+	AliyunBatchItemResult struct {
+		Mobile string     `form:"mobile" json:"mobile"` //接收手机号
+		Result *SmsResult `form:"result" json:"result"` //所在分组请求的发送结果（每个接收者独立的副本）
+		Error  error      `form:"-" json:"-"`           //所在分组请求的错误
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 批量发送短信，超过AliyunBatchSize时自动分组请求
+ * 发送前校验全部接收者，任一接收者不合法时不发送任何分组
+ * 某个分组失败不影响其它分组，返回第一个分组错误，每个接收者的结果见Items
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) SendBatch(ctx context.Context, batchMessage *AliyunBatchMessage) (*AliyunBatchResult, error) {
+	result := new(AliyunBatchResult)
+	result.IsSuccess = false
+
+	if batchMessage == nil || len(batchMessage.Items) == 0 {
+		return result, ErrInvalidMobile
+	}
+
+	//合并默认值
+	templateCode, items, err := s.resolveBatch(batchMessage)
+	if err != nil {
+		return result, err
+	}
+
+	var firstErr error
+	for start := 0; start < len(items); start += AliyunBatchSize {
+		end := start + AliyunBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		chunkResult, err := s.sendBatchChunk(ctx, templateCode, batchMessage.OutId, items[start:end])
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		for _, item := range items[start:end] {
+			itemResult := *chunkResult
+			result.Items = append(result.Items, &AliyunBatchItemResult{
+				Mobile: item.Mobile,
+				Result: &itemResult,
+				Error:  err,
+			})
+		}
+	}
+
+	result.IsSuccess = firstErr == nil
+
+	return result, firstErr
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送一个分组
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) sendBatchChunk(ctx context.Context, templateCode, outId string, items []AliyunBatchItem) (*SmsResult, error) {
+	result := new(SmsResult)
+	result.IsSuccess = false
+	result.OutId = outId
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	mobiles := make([]string, 0, len(items))
+	signNames := make([]string, 0, len(items))
+	extendCodes := make([]string, 0, len(items))
+	hasExtendCode := false
+	templateParams := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		mobiles = append(mobiles, item.Mobile)
+		signNames = append(signNames, item.SignName)
+		extendCodes = append(extendCodes, item.ExtendCode)
+		hasExtendCode = hasExtendCode || len(item.ExtendCode) > 0
+
+		templateParam, err := item.TemplateParam.MarshalJSON()
+		if err != nil {
+			return result, err
+		}
+		templateParams = append(templateParams, templateParam)
+	}
+
+	//业务参数
+	params := make(map[string]string, 0)
+	params["TemplateCode"] = templateCode
+	params["PhoneNumberJson"] = aliyunJsonString(mobiles)
+	params["SignNameJson"] = aliyunJsonString(signNames)
+	params["TemplateParamJson"] = aliyunJsonString(templateParams)
+	params["OutId"] = outId
+
+	if hasExtendCode {
+		params["SmsUpExtendCodeJson"] = aliyunJsonString(extendCodes)
+	}
+
+	//发送请求
+	var resultResponse AliyunSmsSendResultResponse
+	if err := s.call(ctx, "SendBatchSms", params, &resultResponse); err != nil {
+		return result, err
+	}
+
+	result.Code = resultResponse.Code
+	result.Message = resultResponse.Message
+	result.RequestId = resultResponse.RequestId
+	result.Model = resultResponse.BizId
+
+	if err := aliyunResponseError(resultResponse.Code, resultResponse.Message, resultResponse.RequestId); err != nil {
+		return result, err
+	}
+
+	result.IsSuccess = true
+
+	return result, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 合并批量短信默认值并校验，手机号转换为阿里云格式
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) resolveBatch(batchMessage *AliyunBatchMessage) (string, []AliyunBatchItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templateCode := batchMessage.TemplateCode
+	if len(templateCode) == 0 {
+		templateCode = s.TemplateCode
+	}
+
+	if len(templateCode) == 0 {
+		return "", nil, ErrTemplateMissing
+	}
+
+	defaultParam := s.TemplateParam
+	if defaultParam == nil && len(s.ParamString) > 0 {
+		if err := json.Unmarshal([]byte(s.ParamString), &defaultParam); err != nil {
+			return "", nil, err
+		}
+	}
+
+	items := make([]AliyunBatchItem, 0, len(batchMessage.Items))
+	for _, item := range batchMessage.Items {
+		mobile, err := aliyunMobile(item.Mobile)
+		if err != nil {
+			return "", nil, err
+		}
+		item.Mobile = mobile
+
+		if len(item.SignName) == 0 {
+			item.SignName = s.SignName
+		}
+
+		if len(item.SignName) == 0 {
+			return "", nil, ErrSignNameInvalid
+		}
+
+		if item.TemplateParam == nil {
+			item.TemplateParam = defaultParam
+		}
+
+		items = append(items, item)
+	}
+
+	return templateCode, items, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 序列化为Json数组字符串
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func aliyunJsonString(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,76 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func newAliyunBatchTestServer(t *testing.T, requests *int32) AliyunSmsProvider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(requests, 1)
+		fmt.Fprintf(w, `{"Code":"OK","Message":"OK","RequestId":"req-%d","BizId":"biz-%d"}`, n, n)
+	}))
+	t.Cleanup(server.Close)
+
+	sms := NewAliyunSms("id", "secret", "", "sign")
+	sms.SetGeteway(server.URL)
+	sms.SetTemplateCode("SMS_1")
+
+	return sms
+}
+
+func TestAliyunSendBatchValidatesAllItemsFirst(t *testing.T) {
+	var requests int32
+	sms := newAliyunBatchTestServer(t, &requests)
+
+	batchMessage := &AliyunBatchMessage{}
+	for i := 0; i < AliyunBatchSize+50; i++ {
+		batchMessage.Items = append(batchMessage.Items, AliyunBatchItem{Mobile: fmt.Sprintf("138%08d", i)})
+	}
+	batchMessage.Items[AliyunBatchSize+10].Mobile = "138-abc"
+
+	_, err := sms.SendBatch(context.Background(), batchMessage)
+	if !errors.Is(err, ErrInvalidMobile) {
+		t.Fatalf("got %v, want ErrInvalidMobile", err)
+	}
+	if requests != 0 {
+		t.Fatalf("sent %d requests before validation failed", requests)
+	}
+}
+
+func TestAliyunSendBatchResultPerItem(t *testing.T) {
+	var requests int32
+	sms := newAliyunBatchTestServer(t, &requests)
+
+	batchMessage := &AliyunBatchMessage{}
+	for i := 0; i < AliyunBatchSize+1; i++ {
+		batchMessage.Items = append(batchMessage.Items, AliyunBatchItem{Mobile: fmt.Sprintf("+86 138%08d", i)})
+	}
+
+	result, err := sms.SendBatch(context.Background(), batchMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || len(result.Items) != AliyunBatchSize+1 {
+		t.Fatalf("got %d requests, %d items", requests, len(result.Items))
+	}
+
+	first, second := result.Items[0].Result, result.Items[1].Result
+	if first == second {
+		t.Fatal("items share the same *SmsResult")
+	}
+
+	first.Message = "changed"
+	if second.Message != "OK" {
+		t.Fatalf("changing one item result affected another: %q", second.Message)
+	}
+
+	if result.Items[0].Mobile != "13800000000" || result.Items[AliyunBatchSize].Result.Model != "biz-2" {
+		t.Fatalf("got %#v %#v", result.Items[0], result.Items[AliyunBatchSize].Result)
+	}
+}
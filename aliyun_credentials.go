@@ -0,0 +1,479 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ================================================================================
+ * 阿里云访问凭证
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	AliyunCredentials struct {
+		AccessKeyId     string    `form:"AccessKeyId" json:"AccessKeyId"`
+		AccessKeySecret string    `form:"AccessKeySecret" json:"AccessKeySecret"`
+		SecurityToken   string    `form:"SecurityToken" json:"SecurityToken"` //STS临时凭证令牌
+		Expiration      time.Time `form:"Expiration" json:"Expiration"`       //过期时间，零值表示不过期
+	}
+
+	AliyunCredentialsProvider interface {
+		Credentials(ctx context.Context) (*AliyunCredentials, error)
+	}
+
+	/*
+	 * STS AssumeRole临时凭证，过期前RefreshBefore时间内自动刷新
+	 */
+	AliyunStsCredentials struct {
+		Endpoint        string        `form:"endpoint" json:"endpoint"`                   //STS网关，默认https://sts.aliyuncs.com
+		RoleArn         string        `form:"role_arn" json:"role_arn"`                   //RAM角色ARN
+		RoleSessionName string        `form:"role_session_name" json:"role_session_name"` //会话名称
+		Policy          string        `form:"policy" json:"policy"`                       //权限策略，为空时使用角色的全部权限
+		DurationSeconds int           `form:"duration_seconds" json:"duration_seconds"`   //有效时长，默认3600秒
+		RefreshBefore   time.Duration `form:"refresh_before" json:"refresh_before"`       //提前刷新时间，默认5分钟
+		base            AliyunCredentialsProvider
+		options         []Option
+		client          *aliyunSms
+		credentials     *AliyunCredentials
+		refreshing      chan struct{} //刷新中，刷新结束时关闭
+		mu              sync.Mutex
+	}
+
+	/*
+	 * 凭证链中所有提供者都失败时的错误，按顺序保留每个提供者的原始错误
+	 * errors.Is(err, ErrAuth)为true，errors.Is和errors.As可匹配任一原始错误（如context.Canceled、ErrTransport）
+	 */
+	AliyunCredentialsChainError struct {
+		Errors []error
+	}
+
+	aliyunStaticCredentials struct {
+		credentials AliyunCredentials
+	}
+
+	aliyunEnvCredentials struct{}
+
+	aliyunFileCredentials struct {
+		path     string
+		profile  string
+		opts     []Option
+		provider AliyunCredentialsProvider
+		mu       sync.Mutex
+	}
+
+	aliyunCredentialsChain struct {
+		providers []AliyunCredentialsProvider
+	}
+
+	aliyunStsResponse struct {
+		Code        string `form:"Code" json:"Code" xml:"Code"`
+		Message     string `form:"Message" json:"Message" xml:"Message"`
+		RequestId   string `form:"RequestId" json:"RequestId" xml:"RequestId"`
+		Credentials struct {
+			AccessKeyId     string `form:"AccessKeyId" json:"AccessKeyId" xml:"AccessKeyId"`
+			AccessKeySecret string `form:"AccessKeySecret" json:"AccessKeySecret" xml:"AccessKeySecret"`
+			SecurityToken   string `form:"SecurityToken" json:"SecurityToken" xml:"SecurityToken"`
+			Expiration      string `form:"Expiration" json:"Expiration" xml:"Expiration"`
+		} `form:"Credentials" json:"Credentials" xml:"Credentials"`
+	}
+
+	//阿里云命令行工具配置文件（~/.aliyun/config.json）
+	aliyunCliConfig struct {
+		Current  string `json:"current"`
+		Profiles []struct {
+			Name            string `json:"name"`
+			Mode            string `json:"mode"`
+			AccessKeyId     string `json:"access_key_id"`
+			AccessKeySecret string `json:"access_key_secret"`
+			StsToken        string `json:"sts_token"`
+			RamRoleArn      string `json:"ram_role_arn"`
+			RamSessionName  string `json:"ram_session_name"`
+			ExpiredSeconds  int    `json:"expired_seconds"`
+		} `json:"profiles"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置阿里云凭证提供者，设置后忽略NewAliyunSms的accessKeyId和accessKeySecret
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithAliyunCredentials(provider AliyunCredentialsProvider) Option {
+	return func(o *options) {
+		o.aliyunCredentials = provider
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 静态凭证，securityToken为空时为普通access key
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunStaticCredentials(accessKeyId, accessKeySecret, securityToken string) AliyunCredentialsProvider {
+	return &aliyunStaticCredentials{
+		credentials: AliyunCredentials{
+			AccessKeyId:     accessKeyId,
+			AccessKeySecret: accessKeySecret,
+			SecurityToken:   securityToken,
+		},
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 环境变量凭证
+ * ALIBABA_CLOUD_ACCESS_KEY_ID、ALIBABA_CLOUD_ACCESS_KEY_SECRET、ALIBABA_CLOUD_SECURITY_TOKEN
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunEnvCredentials() AliyunCredentialsProvider {
+	return new(aliyunEnvCredentials)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 阿里云命令行工具格式的凭证文件
+ * path为空时使用~/.aliyun/config.json，profile为空时使用文件中的current
+ * 支持AK、StsToken、RamRoleArn模式，RamRoleArn模式通过STS获取临时凭证
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunFileCredentials(path, profile string, opts ...Option) AliyunCredentialsProvider {
+	return &aliyunFileCredentials{
+		path:    path,
+		profile: profile,
+		opts:    opts,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * STS AssumeRole临时凭证，base为扮演角色的RAM用户凭证
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunStsCredentials(base AliyunCredentialsProvider, roleArn, roleSessionName string, opts ...Option) *AliyunStsCredentials {
+	return &AliyunStsCredentials{
+		Endpoint:        "https://sts.aliyuncs.com",
+		RoleArn:         roleArn,
+		RoleSessionName: roleSessionName,
+		DurationSeconds: 3600,
+		RefreshBefore:   5 * time.Minute,
+		base:            base,
+		options:         opts,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 凭证链，按顺序返回第一个可用的凭证
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunCredentialsChain(providers ...AliyunCredentialsProvider) AliyunCredentialsProvider {
+	return &aliyunCredentialsChain{
+		providers: providers,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 默认凭证链：环境变量、~/.aliyun/config.json
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunDefaultCredentials(opts ...Option) AliyunCredentialsProvider {
+	return NewAliyunCredentialsChain(
+		NewAliyunEnvCredentials(),
+		NewAliyunFileCredentials("", "", opts...),
+	)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 静态凭证
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *aliyunStaticCredentials) Credentials(ctx context.Context) (*AliyunCredentials, error) {
+	if len(c.credentials.AccessKeyId) == 0 || len(c.credentials.AccessKeySecret) == 0 {
+		return nil, fmt.Errorf("%w: access key为空", ErrAuth)
+	}
+
+	credentials := c.credentials
+
+	return &credentials, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 环境变量凭证
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *aliyunEnvCredentials) Credentials(ctx context.Context) (*AliyunCredentials, error) {
+	accessKeyId := os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_ID")
+	accessKeySecret := os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_SECRET")
+	if len(accessKeyId) == 0 || len(accessKeySecret) == 0 {
+		return nil, fmt.Errorf("%w: 环境变量ALIBABA_CLOUD_ACCESS_KEY_ID或ALIBABA_CLOUD_ACCESS_KEY_SECRET为空", ErrAuth)
+	}
+
+	return &AliyunCredentials{
+		AccessKeyId:     accessKeyId,
+		AccessKeySecret: accessKeySecret,
+		SecurityToken:   os.Getenv("ALIBABA_CLOUD_SECURITY_TOKEN"),
+	}, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 凭证文件，首次调用时读取文件
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *aliyunFileCredentials) Credentials(ctx context.Context) (*AliyunCredentials, error) {
+	c.mu.Lock()
+	if c.provider == nil {
+		provider, err := c.load()
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		c.provider = provider
+	}
+	provider := c.provider
+	c.mu.Unlock()
+
+	return provider.Credentials(ctx)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 读取凭证文件并创建对应模式的凭证
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *aliyunFileCredentials) load() (AliyunCredentialsProvider, error) {
+	path := c.path
+	if len(path) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAuth, err)
+		}
+		path = filepath.Join(home, ".aliyun", "config.json")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuth, err)
+	}
+
+	var config aliyunCliConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%w: %s %v", ErrAuth, path, err)
+	}
+
+	profileName := c.profile
+	if len(profileName) == 0 {
+		profileName = config.Current
+	}
+	if len(profileName) == 0 {
+		profileName = "default"
+	}
+
+	for _, profile := range config.Profiles {
+		if profile.Name != profileName {
+			continue
+		}
+
+		switch strings.ToLower(profile.Mode) {
+		case "", "ak":
+			return NewAliyunStaticCredentials(profile.AccessKeyId, profile.AccessKeySecret, ""), nil
+		case "ststoken":
+			return NewAliyunStaticCredentials(profile.AccessKeyId, profile.AccessKeySecret, profile.StsToken), nil
+		case "ramrolearn":
+			base := NewAliyunStaticCredentials(profile.AccessKeyId, profile.AccessKeySecret, "")
+			stsCredentials := NewAliyunStsCredentials(base, profile.RamRoleArn, profile.RamSessionName, c.opts...)
+			if profile.ExpiredSeconds > 0 {
+				stsCredentials.DurationSeconds = profile.ExpiredSeconds
+			}
+			return stsCredentials, nil
+		}
+
+		return nil, fmt.Errorf("%w: 不支持的凭证模式 %s", ErrAuth, profile.Mode)
+	}
+
+	return nil, fmt.Errorf("%w: %s中不存在配置 %s", ErrAuth, path, profileName)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 凭证链
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *aliyunCredentialsChain) Credentials(ctx context.Context) (*AliyunCredentials, error) {
+	chainError := &AliyunCredentialsChainError{
+		Errors: make([]error, 0, len(c.providers)),
+	}
+
+	for _, provider := range c.providers {
+		credentials, err := provider.Credentials(ctx)
+		if err == nil {
+			return credentials, nil
+		}
+
+		chainError.Errors = append(chainError.Errors, err)
+
+		//调用已取消或超时时不再尝试后面的提供者
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, chainError
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 错误信息
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *AliyunCredentialsChainError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("%v: 没有可用的凭证 [%s]", ErrAuth, strings.Join(messages, "; "))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 匹配ErrAuth或任一提供者的错误
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *AliyunCredentialsChainError) Is(target error) bool {
+	if target == ErrAuth {
+		return true
+	}
+
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按顺序匹配提供者的错误类型（如*SmsError、*TransportError）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *AliyunCredentialsChainError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 最后一个提供者的错误
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *AliyunCredentialsChainError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e.Errors[len(e.Errors)-1]
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * STS临时凭证，缓存到过期前RefreshBefore时间
+ * 同一时间只有一个调用请求STS，刷新期间其它调用使用未过期的旧凭证或等待刷新结果
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunStsCredentials) Credentials(ctx context.Context) (*AliyunCredentials, error) {
+	for {
+		c.mu.Lock()
+
+		//使用阿里云请求签名调用STS
+		if c.client == nil {
+			opts := append([]Option{}, c.options...)
+			c.client = newAliyunSms("", "", "", "", append(opts, WithAliyunCredentials(c.base))...)
+			c.client.Version = "2015-04-01"
+		}
+
+		now := c.client.options.now()
+		if c.credentials != nil && now.Add(c.RefreshBefore).Before(c.credentials.Expiration) {
+			credentials := *c.credentials
+			c.mu.Unlock()
+			return &credentials, nil
+		}
+
+		//其它调用正在刷新
+		if refreshing := c.refreshing; refreshing != nil {
+			if c.credentials != nil && now.Before(c.credentials.Expiration) {
+				credentials := *c.credentials
+				c.mu.Unlock()
+				return &credentials, nil
+			}
+
+			c.mu.Unlock()
+
+			select {
+			case <-refreshing:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		refreshing := make(chan struct{})
+		c.refreshing = refreshing
+		client := c.client
+		client.SetGeteway(c.Endpoint)
+		c.mu.Unlock()
+
+		//请求STS时不持有锁
+		credentials, err := c.assumeRole(ctx, client)
+
+		c.mu.Lock()
+		c.refreshing = nil
+		if err == nil {
+			c.credentials = credentials
+		}
+		close(refreshing)
+		c.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+
+		result := *credentials
+
+		return &result, nil
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 调用STS AssumeRole获取临时凭证
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunStsCredentials) assumeRole(ctx context.Context, client *aliyunSms) (*AliyunCredentials, error) {
+	if len(c.RoleArn) == 0 {
+		return nil, fmt.Errorf("%w: RoleArn为空", ErrAuth)
+	}
+
+	sessionName := c.RoleSessionName
+	if len(sessionName) == 0 {
+		sessionName = "gsms"
+	}
+
+	params := make(map[string]string, 0)
+	params["RoleArn"] = c.RoleArn
+	params["RoleSessionName"] = sessionName
+	params["Policy"] = c.Policy
+	if c.DurationSeconds > 0 {
+		params["DurationSeconds"] = fmt.Sprintf("%d", c.DurationSeconds)
+	}
+
+	var response aliyunStsResponse
+	if err := client.call(ctx, "AssumeRole", params, &response); err != nil {
+		return nil, err
+	}
+
+	//STS成功响应没有Code字段，错误响应的Code为STS错误码（如NoPermission）
+	if len(response.Code) > 0 {
+		return nil, newSmsError("aliyun", aliyunErrorCodes, response.Code, response.Message, response.RequestId)
+	}
+
+	if len(response.Credentials.AccessKeyId) == 0 {
+		return nil, fmt.Errorf("%w: STS响应缺少临时凭证 RequestId=%s", ErrProvider, response.RequestId)
+	}
+
+	expiration, err := time.Parse(time.RFC3339, response.Credentials.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: STS凭证过期时间格式不正确 %s", ErrAuth, response.Credentials.Expiration)
+	}
+
+	return &AliyunCredentials{
+		AccessKeyId:     response.Credentials.AccessKeyId,
+		AccessKeySecret: response.Credentials.AccessKeySecret,
+		SecurityToken:   response.Credentials.SecurityToken,
+		Expiration:      expiration,
+	}, nil
+}
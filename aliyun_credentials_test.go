@@ -0,0 +1,269 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 可调整的测试时钟
+type testClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// 模拟STS AssumeRole，每次返回新的AccessKeyId，过期时间为时钟当前时间加1小时
+func newFakeSts(t *testing.T, clock *testClock, handle func(n int32)) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if r.FormValue("Action") != "AssumeRole" || r.FormValue("RoleArn") != "acs:ram::1:role/sms" {
+			t.Errorf("unexpected request %v", r.Form)
+		}
+
+		if handle != nil {
+			handle(n)
+		}
+
+		fmt.Fprintf(w, `{"RequestId":"r%d","Credentials":{"AccessKeyId":"STS.%d","AccessKeySecret":"secret","SecurityToken":"token","Expiration":"%s"}}`,
+			n, n, clock.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func newTestStsCredentials(endpoint string, clock *testClock) *AliyunStsCredentials {
+	credentials := NewAliyunStsCredentials(NewAliyunStaticCredentials("id", "secret", ""), "acs:ram::1:role/sms", "gsms", WithClock(clock.Now))
+	credentials.Endpoint = endpoint
+
+	return credentials
+}
+
+func TestAliyunStsCredentialsSingleRefresh(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	server, requests := newFakeSts(t, clock, func(n int32) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	credentials := newTestStsCredentials(server.URL, clock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := credentials.Credentials(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if result.AccessKeyId != "STS.1" || result.SecurityToken != "token" {
+				t.Errorf("got %#v", result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Fatalf("got %d STS requests, want 1", n)
+	}
+}
+
+func TestAliyunStsCredentialsServesCachedDuringRefresh(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server, requests := newFakeSts(t, clock, func(n int32) {
+		if n == 2 {
+			close(started)
+			<-release
+		}
+	})
+	credentials := newTestStsCredentials(server.URL, clock)
+
+	if _, err := credentials.Credentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	//进入提前刷新时间，旧凭证尚未过期
+	clock.Add(58 * time.Minute)
+
+	refreshed := make(chan *AliyunCredentials, 1)
+	go func() {
+		result, err := credentials.Credentials(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		refreshed <- result
+	}()
+	<-started
+
+	done := make(chan *AliyunCredentials, 1)
+	go func() {
+		result, _ := credentials.Credentials(context.Background())
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		if result == nil || result.AccessKeyId != "STS.1" {
+			t.Fatalf("got %#v, want cached STS.1", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Credentials blocked while another call was refreshing")
+	}
+
+	close(release)
+	if result := <-refreshed; result == nil || result.AccessKeyId != "STS.2" {
+		t.Fatalf("got %#v, want STS.2", result)
+	}
+
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Fatalf("got %d STS requests, want 2", n)
+	}
+}
+
+func TestAliyunStsCredentialsWaiterHonorsContext(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server, _ := newFakeSts(t, clock, func(n int32) {
+		close(started)
+		<-release
+	})
+	defer close(release)
+	credentials := newTestStsCredentials(server.URL, clock)
+
+	go credentials.Credentials(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := credentials.Credentials(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+// 函数形式的凭证提供者
+type credentialsFunc func(ctx context.Context) (*AliyunCredentials, error)
+
+func (f credentialsFunc) Credentials(ctx context.Context) (*AliyunCredentials, error) {
+	return f(ctx)
+}
+
+// 返回固定错误的凭证提供者
+func failingCredentials(err error) AliyunCredentialsProvider {
+	return credentialsFunc(func(ctx context.Context) (*AliyunCredentials, error) {
+		return nil, err
+	})
+}
+
+func TestAliyunCredentialsChainErrors(t *testing.T) {
+	transportError := &TransportError{Err: fmt.Errorf("dial tcp: connection refused")}
+	chain := NewAliyunCredentialsChain(NewAliyunStaticCredentials("", "", ""), failingCredentials(transportError))
+
+	_, err := chain.Credentials(context.Background())
+
+	var chainError *AliyunCredentialsChainError
+	if !errors.As(err, &chainError) || len(chainError.Errors) != 2 {
+		t.Fatalf("got %#v", err)
+	}
+
+	var matched *TransportError
+	if !errors.Is(err, ErrAuth) || !errors.Is(err, ErrTransport) || !errors.As(err, &matched) || matched != transportError || !IsRetryable(err) {
+		t.Fatalf("got %v", err)
+	}
+
+	//调用取消后不再尝试后面的提供者
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var called bool
+	chain = NewAliyunCredentialsChain(failingCredentials(context.Canceled), credentialsFunc(func(ctx context.Context) (*AliyunCredentials, error) {
+		called = true
+		return nil, nil
+	}))
+	if _, err := chain.Credentials(ctx); !errors.Is(err, context.Canceled) || called || IsRetryable(err) {
+		t.Fatalf("got %v, called %v", err, called)
+	}
+}
+
+func TestAliyunStsCredentialsErrorCode(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		code        string
+		err         error
+	}{
+		{
+			"json", "application/json",
+			`{"RequestId":"r1","HostId":"sts.aliyuncs.com","Code":"NoPermission","Message":"You are not authorized to do this action."}`,
+			"NoPermission", ErrAuth,
+		},
+		{
+			"xml", "text/xml",
+			`<?xml version="1.0" encoding="UTF-8"?><Error><RequestId>r1</RequestId><HostId>sts.aliyuncs.com</HostId>` +
+				`<Code>InvalidParameter.RoleArn</Code><Message>The parameter RoleArn is wrong.</Message></Error>`,
+			"InvalidParameter.RoleArn", ErrInvalidArgument,
+		},
+		{
+			"throttling", "application/json",
+			`{"RequestId":"r1","Code":"Throttling.User","Message":"Request was denied due to user flow control."}`,
+			"Throttling.User", ErrRateLimited,
+		},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", c.contentType)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(c.body))
+		}))
+
+		clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		_, err := newTestStsCredentials(server.URL, clock).Credentials(context.Background())
+		server.Close()
+
+		var smsErr *SmsError
+		if !errors.As(err, &smsErr) || smsErr.Code != c.code || smsErr.RequestId != "r1" || !errors.Is(err, c.err) {
+			t.Errorf("%s: got %#v", c.name, err)
+		}
+	}
+
+	//既没有Code也没有凭证时不返回空错误码的SmsError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"RequestId":"r2"}`))
+	}))
+	defer server.Close()
+
+	clock := &testClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	_, err := newTestStsCredentials(server.URL, clock).Credentials(context.Background())
+
+	var smsErr *SmsError
+	if !errors.Is(err, ErrProvider) || errors.As(err, &smsErr) {
+		t.Fatalf("got %#v", err)
+	}
+}
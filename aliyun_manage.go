@@ -0,0 +1,331 @@
+package gsms
+
+import (
+	"context"
+	"fmt"
+)
+
+/* ================================================================================
+ * 阿里云短信模版和签名管理
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	AliyunTemplateTypeVerifyCode    AliyunTemplateType = 0 //验证码
+	AliyunTemplateTypeNotice        AliyunTemplateType = 1 //短信通知
+	AliyunTemplateTypePromotion     AliyunTemplateType = 2 //推广短信
+	AliyunTemplateTypeInternational AliyunTemplateType = 3 //国际/港澳台消息
+
+	AliyunAuditStatusAuditing AliyunAuditStatus = 0  //审核中
+	AliyunAuditStatusApproved AliyunAuditStatus = 1  //审核通过
+	AliyunAuditStatusRejected AliyunAuditStatus = 2  //审核失败
+	AliyunAuditStatusCanceled AliyunAuditStatus = 10 //取消审核
+)
+
+type (
+	AliyunTemplateType int
+	AliyunAuditStatus  int
+
+	AliyunSmsManager interface {
+		AddSmsTemplate(ctx context.Context, template *AliyunSmsTemplate) (string, error)
+		ModifySmsTemplate(ctx context.Context, template *AliyunSmsTemplate) error
+		DeleteSmsTemplate(ctx context.Context, templateCode string) error
+		QuerySmsTemplate(ctx context.Context, templateCode string) (*AliyunSmsTemplateStatus, error)
+		EnsureTemplateApproved(ctx context.Context, templateCodes ...string) error
+		AddSmsSign(ctx context.Context, sign *AliyunSmsSign) error
+		ModifySmsSign(ctx context.Context, sign *AliyunSmsSign) error
+		DeleteSmsSign(ctx context.Context, signName string) error
+		QuerySmsSign(ctx context.Context, signName string) (*AliyunSmsSignStatus, error)
+		EnsureSignApproved(ctx context.Context, signNames ...string) error
+	}
+
+	AliyunSmsTemplate struct {
+		TemplateCode    string             `form:"TemplateCode" json:"TemplateCode"`       //模版码，修改时必填
+		TemplateType    AliyunTemplateType `form:"TemplateType" json:"TemplateType"`       //模版类型
+		TemplateName    string             `form:"TemplateName" json:"TemplateName"`       //模版名称
+		TemplateContent string             `form:"TemplateContent" json:"TemplateContent"` //模版内容，例如：您的验证码为${code}
+		Remark          string             `form:"Remark" json:"Remark"`                   //申请说明
+	}
+
+	AliyunSmsTemplateStatus struct {
+		TemplateCode    string             `form:"TemplateCode" json:"TemplateCode" xml:"TemplateCode"`
+		TemplateType    AliyunTemplateType `form:"TemplateType" json:"TemplateType" xml:"TemplateType"`
+		TemplateName    string             `form:"TemplateName" json:"TemplateName" xml:"TemplateName"`
+		TemplateContent string             `form:"TemplateContent" json:"TemplateContent" xml:"TemplateContent"`
+		TemplateStatus  AliyunAuditStatus  `form:"TemplateStatus" json:"TemplateStatus" xml:"TemplateStatus"`
+		Reason          string             `form:"Reason" json:"Reason" xml:"Reason"` //审核备注，审核失败时为失败原因
+		CreateDate      string             `form:"CreateDate" json:"CreateDate" xml:"CreateDate"`
+	}
+
+	AliyunSmsSign struct {
+		SignName   string              `form:"SignName" json:"SignName"`     //签名名称
+		SignSource int                 `form:"SignSource" json:"SignSource"` //签名来源：0企事业单位全称或简称 1工信部备案网站 2APP应用 3公众号或小程序 4电商平台店铺名 5商标名
+		Remark     string              `form:"Remark" json:"Remark"`         //申请说明
+		Files      []AliyunSmsSignFile `form:"Files" json:"Files"`           //证明文件
+	}
+
+	AliyunSmsSignFile struct {
+		FileContents string `form:"FileContents" json:"FileContents"` //Base64编码的文件内容
+		FileSuffix   string `form:"FileSuffix" json:"FileSuffix"`     //文件格式，例如：jpg
+	}
+
+	AliyunSmsSignStatus struct {
+		SignName   string            `form:"SignName" json:"SignName" xml:"SignName"`
+		SignStatus AliyunAuditStatus `form:"SignStatus" json:"SignStatus" xml:"SignStatus"`
+		Reason     string            `form:"Reason" json:"Reason" xml:"Reason"`
+		CreateDate string            `form:"CreateDate" json:"CreateDate" xml:"CreateDate"`
+	}
+
+	aliyunManageResponse struct {
+		Code      string `form:"Code" json:"Code" xml:"Code"`
+		Message   string `form:"Message" json:"Message" xml:"Message"`
+		RequestId string `form:"RequestId" json:"RequestId" xml:"RequestId"`
+	}
+
+	aliyunAddTemplateResponse struct {
+		aliyunManageResponse
+		TemplateCode string `form:"TemplateCode" json:"TemplateCode" xml:"TemplateCode"`
+	}
+
+	aliyunTemplateStatusResponse struct {
+		aliyunManageResponse
+		AliyunSmsTemplateStatus
+	}
+
+	aliyunSignStatusResponse struct {
+		aliyunManageResponse
+		AliyunSmsSignStatus
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建阿里云短信模版和签名管理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunSmsManager(accessKeyId, accessKeySecret, regionId string, opts ...Option) AliyunSmsManager {
+	return newAliyunSms(accessKeyId, accessKeySecret, regionId, "", opts...)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 申请短信模版，返回模版码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) AddSmsTemplate(ctx context.Context, template *AliyunSmsTemplate) (string, error) {
+	if template == nil || len(template.TemplateName) == 0 || len(template.TemplateContent) == 0 {
+		return "", ErrInvalidArgument
+	}
+
+	var response aliyunAddTemplateResponse
+	if err := s.call(ctx, "AddSmsTemplate", template.toDict(), &response); err != nil {
+		return "", err
+	}
+
+	if err := aliyunResponseError(response.Code, response.Message, response.RequestId); err != nil {
+		return "", err
+	}
+
+	return response.TemplateCode, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 修改未通过审核的短信模版
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) ModifySmsTemplate(ctx context.Context, template *AliyunSmsTemplate) error {
+	if template == nil || len(template.TemplateCode) == 0 {
+		return ErrTemplateMissing
+	}
+
+	return s.manage(ctx, "ModifySmsTemplate", template.toDict())
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 删除短信模版
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) DeleteSmsTemplate(ctx context.Context, templateCode string) error {
+	if len(templateCode) == 0 {
+		return ErrTemplateMissing
+	}
+
+	return s.manage(ctx, "DeleteSmsTemplate", map[string]string{
+		"TemplateCode": templateCode,
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 查询短信模版审核状态
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) QuerySmsTemplate(ctx context.Context, templateCode string) (*AliyunSmsTemplateStatus, error) {
+	if len(templateCode) == 0 {
+		return nil, ErrTemplateMissing
+	}
+
+	params := map[string]string{
+		"TemplateCode": templateCode,
+	}
+
+	var response aliyunTemplateStatusResponse
+	if err := s.call(ctx, "QuerySmsTemplate", params, &response); err != nil {
+		return nil, err
+	}
+
+	if err := aliyunResponseError(response.Code, response.Message, response.RequestId); err != nil {
+		return nil, err
+	}
+
+	return &response.AliyunSmsTemplateStatus, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 检查短信模版是否审核通过，未通过时返回ErrTemplateNotApproved
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) EnsureTemplateApproved(ctx context.Context, templateCodes ...string) error {
+	for _, templateCode := range templateCodes {
+		status, err := s.QuerySmsTemplate(ctx, templateCode)
+		if err != nil {
+			return err
+		}
+
+		if status.TemplateStatus != AliyunAuditStatusApproved {
+			return fmt.Errorf("%w: %s %s %s", ErrTemplateNotApproved, templateCode, status.TemplateStatus, status.Reason)
+		}
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 申请短信签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) AddSmsSign(ctx context.Context, sign *AliyunSmsSign) error {
+	if sign == nil || len(sign.SignName) == 0 {
+		return ErrSignNameInvalid
+	}
+
+	return s.manage(ctx, "AddSmsSign", sign.toDict())
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 修改未通过审核的短信签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) ModifySmsSign(ctx context.Context, sign *AliyunSmsSign) error {
+	if sign == nil || len(sign.SignName) == 0 {
+		return ErrSignNameInvalid
+	}
+
+	return s.manage(ctx, "ModifySmsSign", sign.toDict())
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 删除短信签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) DeleteSmsSign(ctx context.Context, signName string) error {
+	if len(signName) == 0 {
+		return ErrSignNameInvalid
+	}
+
+	return s.manage(ctx, "DeleteSmsSign", map[string]string{
+		"SignName": signName,
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 查询短信签名审核状态
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) QuerySmsSign(ctx context.Context, signName string) (*AliyunSmsSignStatus, error) {
+	if len(signName) == 0 {
+		return nil, ErrSignNameInvalid
+	}
+
+	params := map[string]string{
+		"SignName": signName,
+	}
+
+	var response aliyunSignStatusResponse
+	if err := s.call(ctx, "QuerySmsSign", params, &response); err != nil {
+		return nil, err
+	}
+
+	if err := aliyunResponseError(response.Code, response.Message, response.RequestId); err != nil {
+		return nil, err
+	}
+
+	return &response.AliyunSmsSignStatus, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 检查短信签名是否审核通过，未通过时返回ErrSignNameInvalid
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) EnsureSignApproved(ctx context.Context, signNames ...string) error {
+	for _, signName := range signNames {
+		status, err := s.QuerySmsSign(ctx, signName)
+		if err != nil {
+			return err
+		}
+
+		if status.SignStatus != AliyunAuditStatusApproved {
+			return fmt.Errorf("%w: %s %s %s", ErrSignNameInvalid, signName, status.SignStatus, status.Reason)
+		}
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 调用管理接口并检查响应码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) manage(ctx context.Context, action string, params map[string]string) error {
+	var response aliyunManageResponse
+	if err := s.call(ctx, action, params, &response); err != nil {
+		return err
+	}
+
+	return aliyunResponseError(response.Code, response.Message, response.RequestId)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 模版请求参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (t *AliyunSmsTemplate) toDict() map[string]string {
+	params := make(map[string]string, 0)
+	params["TemplateCode"] = t.TemplateCode
+	params["TemplateType"] = fmt.Sprintf("%d", t.TemplateType)
+	params["TemplateName"] = t.TemplateName
+	params["TemplateContent"] = t.TemplateContent
+	params["Remark"] = t.Remark
+
+	return params
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 签名请求参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (t *AliyunSmsSign) toDict() map[string]string {
+	params := make(map[string]string, 0)
+	params["SignName"] = t.SignName
+	params["SignSource"] = fmt.Sprintf("%d", t.SignSource)
+	params["Remark"] = t.Remark
+
+	for i, file := range t.Files {
+		params[fmt.Sprintf("SignFileList.%d.FileContents", i+1)] = file.FileContents
+		params[fmt.Sprintf("SignFileList.%d.FileSuffix", i+1)] = file.FileSuffix
+	}
+
+	return params
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 审核状态描述
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (status AliyunAuditStatus) String() string {
+	switch status {
+	case AliyunAuditStatusAuditing:
+		return "auditing"
+	case AliyunAuditStatusApproved:
+		return "approved"
+	case AliyunAuditStatusRejected:
+		return "rejected"
+	case AliyunAuditStatusCanceled:
+		return "canceled"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(status))
+}
@@ -0,0 +1,281 @@
+package gsms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+/* ================================================================================
+ * 阿里云消息服务（MNS）短信回执和上行短信消费
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	/*
+	 * 长轮询MNS队列，解析SmsReport和SmsUp消息后交给回调处理
+	 * 回调返回nil时删除消息，返回错误时保留消息，可见性超时后重新消费
+	 * 无法解析、未设置对应回调或消费次数达到MaxDequeueCount的消息交给OnError后删除，避免反复投递
+	 */
+	AliyunMnsConsumer struct {
+		Endpoint        string                    `form:"endpoint" json:"endpoint"`                   //队列地址，例如：https://{AccountId}.mns.cn-hangzhou.aliyuncs.com
+		QueueName       string                    `form:"queue_name" json:"queue_name"`               //队列名称
+		WaitSeconds     int                       `form:"wait_seconds" json:"wait_seconds"`           //长轮询等待时间，1~30秒，默认30
+		MaxDequeueCount int                       `form:"max_dequeue_count" json:"max_dequeue_count"` //最大消费次数，回调失败达到该次数后删除消息，默认10
+		Credentials     AliyunCredentialsProvider `form:"-" json:"-"`                                 //访问凭证
+		OnReport        DeliveryReportHandler     `form:"-" json:"-"`                                 //短信回执回调
+		OnInbound       InboundMessageHandler     `form:"-" json:"-"`                                 //上行短信回调
+		OnError         func(err error)           `form:"-" json:"-"`                                 //消费失败和删除消息的回调，为空时忽略
+		options         options
+	}
+
+	aliyunMnsMessage struct {
+		MessageId     string `xml:"MessageId"`
+		ReceiptHandle string `xml:"ReceiptHandle"`
+		MessageBody   string `xml:"MessageBody"`
+		DequeueCount  int    `xml:"DequeueCount"`
+	}
+
+	aliyunMnsError struct {
+		Code      string `xml:"Code"`
+		Message   string `xml:"Message"`
+		RequestId string `xml:"RequestId"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建MNS队列消费者，credentials为空时使用NewAliyunDefaultCredentials
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunMnsConsumer(endpoint, queueName string, credentials AliyunCredentialsProvider, opts ...Option) *AliyunMnsConsumer {
+	consumer := new(AliyunMnsConsumer)
+	consumer.Endpoint = strings.TrimRight(endpoint, "/")
+	consumer.QueueName = queueName
+	consumer.WaitSeconds = 30
+	consumer.MaxDequeueCount = 10
+	consumer.Credentials = credentials
+	consumer.options = newOptions(opts)
+
+	if consumer.Credentials == nil {
+		consumer.Credentials = NewAliyunDefaultCredentials(opts...)
+	}
+
+	return consumer
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 持续消费队列，直到ctx取消
+ * 消息处理失败和可重试的接收错误交给OnError后继续，其它接收错误（凭证、队列不存在等）直接返回
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunMnsConsumer) Run(ctx context.Context) error {
+	if c.OnReport == nil && c.OnInbound == nil {
+		return fmt.Errorf("%w: 未设置OnReport或OnInbound回调", ErrInvalidArgument)
+	}
+
+	for {
+		isReceived, err := c.ReceiveOnce(ctx)
+		if err == nil {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		//已接收的消息处理失败时保留在队列中，可见性超时后重新消费
+		if !isReceived && !IsRetryable(err) {
+			return err
+		}
+
+		c.onError(err)
+
+		//出错后等待一段时间再重试，避免频繁请求
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 接收并处理一条消息，队列为空时返回false
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunMnsConsumer) ReceiveOnce(ctx context.Context) (bool, error) {
+	waitSeconds := c.WaitSeconds
+	if waitSeconds <= 0 || waitSeconds > 30 {
+		waitSeconds = 30
+	}
+
+	resource := fmt.Sprintf("/queues/%s/messages?waitseconds=%d", c.QueueName, waitSeconds)
+	response, err := c.request(ctx, http.MethodGet, resource)
+	if err != nil {
+		return false, err
+	}
+
+	//队列中没有消息
+	if response.StatusCode == http.StatusNotFound && strings.Contains(response.Body, "MessageNotExist") {
+		return false, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return false, c.responseError(response)
+	}
+
+	var message aliyunMnsMessage
+	if err := response.decode("xml", &message); err != nil {
+		return false, err
+	}
+
+	isDropped, err := c.handle(ctx, &message)
+	if err != nil {
+		maxDequeueCount := c.MaxDequeueCount
+		if maxDequeueCount <= 0 {
+			maxDequeueCount = 10
+		}
+
+		if !isDropped && message.DequeueCount < maxDequeueCount {
+			return true, err
+		}
+
+		//无法处理的消息交给OnError后删除
+		c.onError(fmt.Errorf("删除第%d次消费失败的消息 %s: %w", message.DequeueCount, message.MessageId, err))
+	}
+
+	return true, c.delete(ctx, message.ReceiptHandle)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析消息内容并调用回调，消息无法解析或未设置对应回调时返回true，重新消费也无法处理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunMnsConsumer) handle(ctx context.Context, message *aliyunMnsMessage) (bool, error) {
+	//消息内容为Json，部分队列为Base64编码的Json
+	body := []byte(message.MessageBody)
+	if !json.Valid(body) {
+		decoded, err := base64.StdEncoding.DecodeString(message.MessageBody)
+		if err != nil {
+			return true, fmt.Errorf("%w: 消息内容格式不正确 %s", ErrProvider, message.MessageId)
+		}
+		body = decoded
+	}
+
+	report, inbound, err := parseAliyunSmsMessage(body)
+	if err != nil {
+		return true, fmt.Errorf("%w: 消息内容解析失败 %s %v", ErrProvider, message.MessageId, err)
+	}
+
+	if report != nil {
+		if c.OnReport == nil {
+			return true, fmt.Errorf("%w: 未设置OnReport回调 %s", ErrInvalidArgument, message.MessageId)
+		}
+
+		return false, c.OnReport(ctx, report)
+	}
+
+	if c.OnInbound == nil {
+		return true, fmt.Errorf("%w: 未设置OnInbound回调 %s", ErrInvalidArgument, message.MessageId)
+	}
+
+	return false, c.OnInbound(ctx, inbound)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 调用OnError回调
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunMnsConsumer) onError(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 删除已处理的消息
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunMnsConsumer) delete(ctx context.Context, receiptHandle string) error {
+	resource := fmt.Sprintf("/queues/%s/messages?ReceiptHandle=%s", c.QueueName, url.QueryEscape(receiptHandle))
+	response, err := c.request(ctx, http.MethodDelete, resource)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusOK {
+		return c.responseError(response)
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 签名并发送MNS请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunMnsConsumer) request(ctx context.Context, method, resource string) (*httpResponse, error) {
+	if c.Credentials == nil {
+		return nil, fmt.Errorf("%w: 未设置访问凭证", ErrAuth)
+	}
+
+	credentials, err := c.Credentials.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(map[string]string, 0)
+	header["Content-Type"] = "text/xml;charset=utf-8"
+	header["Date"] = c.options.now().UTC().Format(http.TimeFormat)
+	header["x-mns-version"] = "2015-06-06"
+
+	if len(credentials.SecurityToken) > 0 {
+		header["security-token"] = credentials.SecurityToken
+	}
+
+	signature := aliyunMnsSignature(credentials.AccessKeySecret, method, resource, header)
+	header["Authorization"] = fmt.Sprintf("MNS %s:%s", credentials.AccessKeyId, signature)
+
+	return c.options.httpDo(ctx, method, c.Endpoint+resource, header, "")
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * MNS错误响应
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *AliyunMnsConsumer) responseError(response *httpResponse) error {
+	var mnsError aliyunMnsError
+	if err := response.decode("xml", &mnsError); err != nil {
+		return err
+	}
+
+	return newSmsError("aliyun", aliyunErrorCodes, mnsError.Code, mnsError.Message, mnsError.RequestId)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * MNS签名
+ * VERB\nContent-MD5\nContent-Type\nDate\nCanonicalizedMNSHeaders + CanonicalizedResource
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func aliyunMnsSignature(accessKeySecret, method, resource string, header map[string]string) string {
+	mnsHeaders := make([]string, 0)
+	for key, value := range header {
+		if key = strings.ToLower(key); strings.HasPrefix(key, "x-mns-") {
+			mnsHeaders = append(mnsHeaders, fmt.Sprintf("%s:%s\n", key, value))
+		}
+	}
+	sort.Strings(mnsHeaders)
+
+	stringToSign := strings.Join([]string{
+		method,
+		header["Content-MD5"],
+		header["Content-Type"],
+		header["Date"],
+		strings.Join(mnsHeaders, "") + resource,
+	}, "\n")
+
+	mac := hmac.New(sha1.New, []byte(accessKeySecret))
+	mac.Write([]byte(stringToSign))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,246 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testMnsReport = `{"phone_number":"13800000000","success":true,"biz_id":"biz-1","out_id":"out-1","send_time":"2024-01-01 10:00:00","report_time":"2024-01-01 10:00:05","err_code":"DELIVERED","err_msg":"用户接收成功","sms_size":"1"}`
+
+// 模拟MNS队列，messages依次出队，出队后为空队列
+type fakeMns struct {
+	messages []string
+	dequeue  int
+	status   int
+	body     string
+	deleted  []string
+	mu       sync.Mutex
+}
+
+func (q *fakeMns) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "MNS id:") || r.URL.Path != "/queues/q/messages" {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>denied</Message><RequestId>r1</RequestId></Error>`)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		q.deleted = append(q.deleted, r.URL.Query().Get("ReceiptHandle"))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if q.status != 0 {
+		w.WriteHeader(q.status)
+		fmt.Fprint(w, q.body)
+		return
+	}
+
+	if len(q.messages) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<Error><Code>MessageNotExist</Code><Message>Message not exist.</Message></Error>`)
+		return
+	}
+
+	dequeue := q.dequeue
+	if dequeue == 0 {
+		dequeue = 1
+	}
+
+	body := q.messages[0]
+	q.messages = q.messages[1:]
+	fmt.Fprintf(w, `<Message><MessageId>m1</MessageId><ReceiptHandle>rh-1</ReceiptHandle><MessageBody>%s</MessageBody><DequeueCount>%d</DequeueCount></Message>`, body, dequeue)
+}
+
+func (q *fakeMns) deletedHandles() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]string(nil), q.deleted...)
+}
+
+func newTestMnsConsumer(t *testing.T, queue *fakeMns) *AliyunMnsConsumer {
+	server := httptest.NewServer(queue)
+	t.Cleanup(server.Close)
+
+	return NewAliyunMnsConsumer(server.URL, "q", NewAliyunStaticCredentials("id", "secret", ""))
+}
+
+func TestAliyunMnsConsumerDeletesHandledMessage(t *testing.T) {
+	queue := &fakeMns{messages: []string{testMnsReport}}
+	consumer := newTestMnsConsumer(t, queue)
+
+	var report *DeliveryReport
+	consumer.OnReport = func(ctx context.Context, r *DeliveryReport) error {
+		report = r
+		return nil
+	}
+
+	isReceived, err := consumer.ReceiveOnce(context.Background())
+	if err != nil || !isReceived {
+		t.Fatalf("got %v %v", isReceived, err)
+	}
+	if report == nil || report.MessageId != "biz-1" || report.OutId != "out-1" {
+		t.Fatalf("got %#v", report)
+	}
+	if deleted := queue.deletedHandles(); len(deleted) != 1 || deleted[0] != "rh-1" {
+		t.Fatalf("got deleted %v", deleted)
+	}
+
+	isReceived, err = consumer.ReceiveOnce(context.Background())
+	if err != nil || isReceived {
+		t.Fatalf("empty queue: got %v %v", isReceived, err)
+	}
+}
+
+func TestAliyunMnsConsumerDropsUnprocessableMessage(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{"no handler", testMnsReport, ErrInvalidArgument},
+		{"bad body", "not json or base64!", ErrProvider},
+		{"bad json", "eyJwaG9uZV9udW1iZXIiOjEyM30=", ErrProvider}, //{"phone_number":123}
+	}
+
+	for _, c := range cases {
+		queue := &fakeMns{messages: []string{c.body}}
+		consumer := newTestMnsConsumer(t, queue)
+		consumer.OnInbound = func(ctx context.Context, message *InboundMessage) error {
+			t.Fatalf("%s: OnInbound should not be called", c.name)
+			return nil
+		}
+
+		var errs []error
+		consumer.OnError = func(err error) {
+			errs = append(errs, err)
+		}
+
+		isReceived, err := consumer.ReceiveOnce(context.Background())
+		if !isReceived || err != nil {
+			t.Fatalf("%s: got %v %v", c.name, isReceived, err)
+		}
+		if len(errs) != 1 || !errors.Is(errs[0], c.err) {
+			t.Fatalf("%s: OnError got %v", c.name, errs)
+		}
+		if deleted := queue.deletedHandles(); len(deleted) != 1 || deleted[0] != "rh-1" {
+			t.Fatalf("%s: got deleted %v", c.name, deleted)
+		}
+	}
+}
+
+func TestAliyunMnsConsumerMaxDequeueCount(t *testing.T) {
+	handlerErr := errors.New("db down")
+
+	for _, dequeue := range []int{2, 3} {
+		queue := &fakeMns{messages: []string{testMnsReport}, dequeue: dequeue}
+		consumer := newTestMnsConsumer(t, queue)
+		consumer.MaxDequeueCount = 3
+		consumer.OnReport = func(ctx context.Context, r *DeliveryReport) error {
+			return handlerErr
+		}
+
+		var errs []error
+		consumer.OnError = func(err error) {
+			errs = append(errs, err)
+		}
+
+		isReceived, err := consumer.ReceiveOnce(context.Background())
+		deleted := queue.deletedHandles()
+
+		//未达到最大消费次数时保留消息，返回回调的错误
+		if dequeue < consumer.MaxDequeueCount {
+			if !isReceived || !errors.Is(err, handlerErr) || len(errs) != 0 || len(deleted) != 0 {
+				t.Fatalf("dequeue %d: got %v %v %v %v", dequeue, isReceived, err, errs, deleted)
+			}
+			continue
+		}
+
+		if !isReceived || err != nil || len(errs) != 1 || !errors.Is(errs[0], handlerErr) || len(deleted) != 1 {
+			t.Fatalf("dequeue %d: got %v %v %v %v", dequeue, isReceived, err, errs, deleted)
+		}
+	}
+}
+
+func TestAliyunMnsConsumerCredentials(t *testing.T) {
+	consumer := NewAliyunMnsConsumer("https://1.mns.cn-hangzhou.aliyuncs.com", "q", nil)
+	if consumer.Credentials == nil {
+		t.Fatal("credentials should default to NewAliyunDefaultCredentials")
+	}
+
+	consumer.Credentials = nil
+	if _, err := consumer.ReceiveOnce(context.Background()); !errors.Is(err, ErrAuth) {
+		t.Fatalf("got %v, want ErrAuth", err)
+	}
+}
+
+func TestAliyunMnsConsumerRunReturnsNonRetryableError(t *testing.T) {
+	queue := &fakeMns{
+		status: http.StatusNotFound,
+		body:   `<Error><Code>QueueNotExist</Code><Message>queue not exist</Message><RequestId>r1</RequestId></Error>`,
+	}
+	consumer := newTestMnsConsumer(t, queue)
+	consumer.OnReport = func(ctx context.Context, r *DeliveryReport) error {
+		return nil
+	}
+
+	var onError int
+	consumer.OnError = func(err error) {
+		onError++
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := consumer.Run(ctx); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("got %v, want QueueNotExist", err)
+	}
+	if onError != 0 {
+		t.Fatalf("OnError called %d times for a non-retryable error", onError)
+	}
+
+	if err := new(AliyunMnsConsumer).Run(ctx); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("Run without handlers: got %v", err)
+	}
+}
+
+func TestAliyunMnsConsumerRunReportsHandlerError(t *testing.T) {
+	queue := &fakeMns{messages: []string{testMnsReport}}
+	consumer := newTestMnsConsumer(t, queue)
+	consumer.WaitSeconds = 1
+
+	handlerErr := errors.New("db down")
+	consumer.OnReport = func(ctx context.Context, r *DeliveryReport) error {
+		return handlerErr
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	consumer.OnError = func(err error) {
+		errs <- err
+		cancel()
+	}
+
+	if err := consumer.Run(ctx); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if err := <-errs; !errors.Is(err, handlerErr) {
+		t.Fatalf("OnError got %v", err)
+	}
+	if deleted := queue.deletedHandles(); len(deleted) != 0 {
+		t.Fatalf("failed message was deleted: %v", deleted)
+	}
+}
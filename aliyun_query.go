@@ -0,0 +1,149 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/* ================================================================================
+ * 阿里云短信发送记录查询（QuerySendDetails）
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	AliyunSendStatusWaiting AliyunSendStatus = 1 //等待回执
+	AliyunSendStatusFailed  AliyunSendStatus = 2 //发送失败
+	AliyunSendStatusSuccess AliyunSendStatus = 3 //发送成功
+)
+
+type (
+	AliyunSendStatus int
+
+	AliyunSendDetailsQuery struct {
+		Mobile      string    `form:"mobile" json:"mobile"`             //接收手机号（必填）
+		BizId       string    `form:"biz_id" json:"biz_id"`             //发送回执Id，即SmsResult.Model
+		SendDate    time.Time `form:"send_date" json:"send_date"`       //发送日期，支持最近30天（必填）
+		PageSize    int       `form:"page_size" json:"page_size"`       //每页记录数，取值1~50，默认10
+		CurrentPage int       `form:"current_page" json:"current_page"` //当前页码，从1开始，默认1
+	}
+
+	AliyunSendDetailsResult struct {
+		Code       string              `form:"code" json:"code"`
+		Message    string              `form:"msg" json:"msg"`
+		RequestId  string              `form:"request_id" json:"request_id"`
+		TotalCount int64               `form:"total_count" json:"total_count"`
+		Details    []*AliyunSendDetail `form:"details" json:"details"`
+	}
+
+	AliyunSendDetail struct {
+		Mobile       string           `form:"PhoneNum" json:"PhoneNum" xml:"PhoneNum"`             //接收手机号
+		SendStatus   AliyunSendStatus `form:"SendStatus" json:"SendStatus" xml:"SendStatus"`       //发送状态
+		ErrCode      string           `form:"ErrCode" json:"ErrCode" xml:"ErrCode"`                //运营商错误码
+		TemplateCode string           `form:"TemplateCode" json:"TemplateCode" xml:"TemplateCode"` //模版码
+		Content      string           `form:"Content" json:"Content" xml:"Content"`                //短信内容
+		SendDate     string           `form:"SendDate" json:"SendDate" xml:"SendDate"`             //发送时间，yyyy-MM-dd HH:mm:ss
+		ReceiveDate  string           `form:"ReceiveDate" json:"ReceiveDate" xml:"ReceiveDate"`    //接收时间，yyyy-MM-dd HH:mm:ss
+		OutId        string           `form:"OutId" json:"OutId" xml:"OutId"`                      //外部流水扩展字段
+	}
+
+	aliyunSendDetailsResponse struct {
+		Code              string      `form:"Code" json:"Code" xml:"Code"`
+		Message           string      `form:"Message" json:"Message" xml:"Message"`
+		RequestId         string      `form:"RequestId" json:"RequestId" xml:"RequestId"`
+		TotalCount        json.Number `form:"TotalCount" json:"TotalCount" xml:"TotalCount"` //数字或字符串
+		SmsSendDetailDTOs struct {
+			SmsSendDetailDTO []*AliyunSendDetail `form:"SmsSendDetailDTO" json:"SmsSendDetailDTO" xml:"SmsSendDetailDTO"`
+		} `form:"SmsSendDetailDTOs" json:"SmsSendDetailDTOs" xml:"SmsSendDetailDTOs"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 查询短信发送记录和回执状态
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) QuerySendDetails(ctx context.Context, query *AliyunSendDetailsQuery) (*AliyunSendDetailsResult, error) {
+	result := new(AliyunSendDetailsResult)
+
+	if query == nil || len(query.Mobile) == 0 {
+		return result, ErrInvalidMobile
+	}
+
+	if query.SendDate.IsZero() {
+		return result, ErrInvalidArgument
+	}
+
+	pageSize := query.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	currentPage := query.CurrentPage
+	if currentPage <= 0 {
+		currentPage = 1
+	}
+
+	//业务参数
+	params := make(map[string]string, 0)
+	params["PhoneNumber"] = query.Mobile
+	params["BizId"] = query.BizId
+	params["SendDate"] = query.SendDate.In(chinaLocation).Format("20060102")
+	params["PageSize"] = fmt.Sprintf("%d", pageSize)
+	params["CurrentPage"] = fmt.Sprintf("%d", currentPage)
+
+	//发送请求
+	var response aliyunSendDetailsResponse
+	if err := s.call(ctx, "QuerySendDetails", params, &response); err != nil {
+		return result, err
+	}
+
+	result.Code = response.Code
+	result.Message = response.Message
+	result.RequestId = response.RequestId
+	result.TotalCount, _ = response.TotalCount.Int64()
+	result.Details = response.SmsSendDetailDTOs.SmsSendDetailDTO
+
+	if err := aliyunResponseError(response.Code, response.Message, response.RequestId); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否已送达
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (d *AliyunSendDetail) IsDelivered() bool {
+	return d.SendStatus == AliyunSendStatusSuccess
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送时间
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (d *AliyunSendDetail) SendTime() time.Time {
+	return parseChinaTime(d.SendDate)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 接收时间，未送达时为零值
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (d *AliyunSendDetail) ReceiveTime() time.Time {
+	return parseChinaTime(d.ReceiveDate)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送状态描述
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (status AliyunSendStatus) String() string {
+	switch status {
+	case AliyunSendStatusWaiting:
+		return "waiting"
+	case AliyunSendStatusFailed:
+		return "failed"
+	case AliyunSendStatusSuccess:
+		return "success"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(status))
+}
@@ -0,0 +1,42 @@
+package gsms
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAliyunQuerySendDetails(t *testing.T) {
+	var form url.Values
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		return newTestResponse(`{"TotalCount":"21","Message":"OK","RequestId":"r1","Code":"OK","SmsSendDetailDTOs":{"SmsSendDetailDTO":[` +
+			`{"SendDate":"2019-01-08 16:44:10","OutId":"123","SendStatus":3,"ReceiveDate":"2019-01-08 16:44:13","ErrCode":"DELIVERED",` +
+			`"TemplateCode":"SMS_122310183","Content":"【阿里云】验证码为：123","PhoneNum":"15298356881"}]}}`), nil
+	})
+
+	sms := NewAliyunSms("id", "secret", "", "sign", WithTransport(transport))
+	result, err := sms.QuerySendDetails(context.Background(), &AliyunSendDetailsQuery{
+		Mobile:      "15298356881",
+		BizId:       "biz-1",
+		SendDate:    time.Date(2019, 1, 7, 20, 0, 0, 0, time.UTC),
+		PageSize:    20,
+		CurrentPage: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Get("Action") != "QuerySendDetails" || form.Get("PhoneNumber") != "15298356881" || form.Get("BizId") != "biz-1" ||
+		form.Get("SendDate") != "20190108" || form.Get("PageSize") != "20" || form.Get("CurrentPage") != "2" {
+		t.Fatalf("got params %v", form)
+	}
+
+	if result.TotalCount != 21 || len(result.Details) != 1 || result.Details[0].OutId != "123" || !result.Details[0].IsDelivered() {
+		t.Fatalf("got %#v", result)
+	}
+}
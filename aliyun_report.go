@@ -0,0 +1,110 @@
+package gsms
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+/* ================================================================================
+ * 阿里云短信回执（SmsReport）和上行短信（SmsUp）消息
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	aliyunSmsReport struct {
+		PhoneNumber string      `json:"phone_number"`
+		Success     bool        `json:"success"`
+		BizId       string      `json:"biz_id"`
+		OutId       string      `json:"out_id"`
+		SendTime    string      `json:"send_time"`
+		ReportTime  string      `json:"report_time"`
+		ErrCode     string      `json:"err_code"`
+		ErrMsg      string      `json:"err_msg"`
+		SmsSize     json.Number `json:"sms_size"`
+	}
+
+	aliyunSmsUp struct {
+		PhoneNumber string      `json:"phone_number"`
+		Content     string      `json:"content"`
+		SignName    string      `json:"sign_name"`
+		DestCode    string      `json:"dest_code"`
+		SequenceId  json.Number `json:"sequence_id"`
+		SendTime    string      `json:"send_time"`
+	}
+
+	//用于判断消息类型
+	aliyunSmsMessage struct {
+		Content    *string `json:"content"`
+		ReportTime *string `json:"report_time"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析阿里云消息，根据字段区分回执和上行短信，返回其中一个
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseAliyunSmsMessage(data []byte) (*DeliveryReport, *InboundMessage, error) {
+	var message aliyunSmsMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, nil, err
+	}
+
+	if message.Content != nil && message.ReportTime == nil {
+		var smsUp aliyunSmsUp
+		if err := json.Unmarshal(data, &smsUp); err != nil {
+			return nil, nil, err
+		}
+
+		return nil, smsUp.toInboundMessage(), nil
+	}
+
+	var smsReport aliyunSmsReport
+	if err := json.Unmarshal(data, &smsReport); err != nil {
+		return nil, nil, err
+	}
+
+	return smsReport.toDeliveryReport(), nil, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为统一回执
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *aliyunSmsReport) toDeliveryReport() *DeliveryReport {
+	report := new(DeliveryReport)
+	report.Provider = "aliyun"
+	report.MessageId = r.BizId
+	report.Mobile = r.PhoneNumber
+	report.ErrorCode = r.ErrCode
+	report.ErrorMessage = r.ErrMsg
+	report.OutId = r.OutId
+	report.SendTime = parseChinaTime(r.SendTime)
+	report.ReportTime = parseChinaTime(r.ReportTime)
+
+	if smsSize, err := strconv.Atoi(r.SmsSize.String()); err == nil {
+		report.SmsSize = smsSize
+	}
+
+	if r.Success {
+		report.Status = DeliveryStatusDelivered
+	} else {
+		report.Status = DeliveryStatusFailed
+	}
+
+	return report
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为统一上行短信
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (m *aliyunSmsUp) toInboundMessage() *InboundMessage {
+	message := new(InboundMessage)
+	message.Provider = "aliyun"
+	message.Mobile = m.PhoneNumber
+	message.Content = m.Content
+	message.SignName = m.SignName
+	message.ExtendCode = m.DestCode
+	message.SequenceId = m.SequenceId.String()
+	message.SendTime = parseChinaTime(m.SendTime)
+
+	return message
+}
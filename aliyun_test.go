@@ -0,0 +1,309 @@
+package gsms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAliyunConcurrentSend(t *testing.T) {
+	sms := NewAliyunSms("id", "secret", "cn-hangzhou", "sign")
+
+	testConcurrentSend(t, sms, "", func(r *http.Request, body []byte) sentMessage {
+		form, _ := url.ParseQuery(string(body))
+		return sentMessage{
+			Mobile:       form.Get("PhoneNumbers"),
+			TemplateCode: form.Get("TemplateCode"),
+			SignName:     form.Get("SignName"),
+			Param:        templateParamCode(form.Get("TemplateParam")),
+			OutId:        form.Get("OutId"),
+		}
+	}, func(sent sentMessage) string {
+		return fmt.Sprintf(`{"Code":"OK","Message":"OK","RequestId":"req-%s","BizId":"biz-%s"}`, sent.OutId, sent.OutId)
+	}, false, false)
+}
+
+// 阿里云POP签名机制文档公布的示例（GET方式），AccessKeySecret分别为testsecret和testSecret
+func TestAliyunSignKnownAnswer(t *testing.T) {
+	sms := NewAliyunSms("testid", "testsecret", "", "sign").(*aliyunSms)
+
+	cases := []struct {
+		name   string
+		secret string
+		params map[string]string
+		want   string
+	}{
+		{
+			"DescribeRegions", "testsecret",
+			map[string]string{
+				"AccessKeyId":      "testid",
+				"Action":           "DescribeRegions",
+				"Format":           "XML",
+				"SignatureMethod":  "HMAC-SHA1",
+				"SignatureNonce":   "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf",
+				"SignatureVersion": "1.0",
+				"Timestamp":        "2016-02-23T12:46:24Z",
+				"Version":          "2014-05-26",
+			},
+			"OLeaidS1JvxuMvnyHOwuJ+uX5qY=",
+		},
+		{
+			"SendSms", "testSecret",
+			map[string]string{
+				"AccessKeyId":      "testId",
+				"Action":           "SendSms",
+				"Format":           "XML",
+				"OutId":            "123",
+				"PhoneNumbers":     "15300000001",
+				"RegionId":         "cn-hangzhou",
+				"SignName":         "阿里云短信测试专用",
+				"SignatureMethod":  "HMAC-SHA1",
+				"SignatureNonce":   "45e25e9b-0a6f-4070-8c85-2956eda1b466",
+				"SignatureVersion": "1.0",
+				"TemplateCode":     "SMS_71390007",
+				"TemplateParam":    `{"customer":"test"}`,
+				"Timestamp":        "2017-07-12T02:42:19Z",
+				"Version":          "2017-05-25",
+			},
+			"zJDF+Lrzhj/ThnlvIToysFRq6t4=",
+		},
+	}
+
+	for _, c := range cases {
+		if got := sms.signString(http.MethodGet, c.params, c.secret); got != sms.PercentEncode(c.want) {
+			t.Errorf("%s: got %s, want %s", c.name, got, sms.PercentEncode(c.want))
+		}
+	}
+}
+
+// 回归向量：使用文档SendSms示例参数按POST方式和JSON格式发送，签名由独立拼接的待签名字符串计算
+func TestAliyunSignRegression(t *testing.T) {
+	var form url.Values
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		return newTestResponse(`{"Code":"OK","Message":"OK","RequestId":"r1","BizId":"b1"}`), nil
+	})
+
+	sms := NewAliyunSms("testId", "testSecret", "cn-hangzhou", "阿里云短信测试专用",
+		WithTransport(transport),
+		WithClock(fixedClock("2017-07-12T02:42:19Z")),
+		WithNonce(fixedNonce("45e25e9b-0a6f-4070-8c85-2956eda1b466")))
+
+	message := NewMessage("15300000001")
+	message.TemplateCode = "SMS_71390007"
+	message.TemplateParam = NewSmsTemplateParams("customer", "test")
+	message.OutId = "123"
+	if _, err := sms.SendMessage(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Get("Timestamp") != "2017-07-12T02:42:19Z" || form.Get("SignatureNonce") != "45e25e9b-0a6f-4070-8c85-2956eda1b466" {
+		t.Fatalf("got %v", form)
+	}
+
+	//待签名字符串独立拼接，不经过GetParamString
+	stringToSign := "POST&%2F&AccessKeyId%3DtestId%26Action%3DSendSms%26Format%3DJSON%26OutId%3D123" +
+		"%26PhoneNumbers%3D15300000001%26RegionId%3Dcn-hangzhou" +
+		"%26SignName%3D%25E9%2598%25BF%25E9%2587%258C%25E4%25BA%2591%25E7%259F%25AD%25E4%25BF%25A1%25E6%25B5%258B%25E8%25AF%2595%25E4%25B8%2593%25E7%2594%25A8" +
+		"%26SignatureMethod%3DHMAC-SHA1%26SignatureNonce%3D45e25e9b-0a6f-4070-8c85-2956eda1b466%26SignatureVersion%3D1.0" +
+		"%26TemplateCode%3DSMS_71390007%26TemplateParam%3D%257B%2522customer%2522%253A%2522test%2522%257D" +
+		"%26Timestamp%3D2017-07-12T02%253A42%253A19Z%26Version%3D2017-05-25"
+	mac := hmac.New(sha1.New, []byte("testSecret&"))
+	mac.Write([]byte(stringToSign))
+	want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if want != "uXFmOZ+G8ZX3qPh4wK0RMGokzcw=" {
+		t.Fatalf("golden signature changed: %s", want)
+	}
+	if got := form.Get("Signature"); got != want {
+		t.Fatalf("got signature %s, want %s", got, want)
+	}
+}
+
+func TestAliyunXmlResponses(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		xml    bool
+		body   string
+		code   string
+		bizId  string
+		err    error
+	}{
+		{
+			"xml format", "xml", true,
+			`<?xml version='1.0' encoding='UTF-8'?><SendSmsResponse><Message>OK</Message><RequestId>F655A8D5-B967-440B-8683-DAD6FF8DE990</RequestId><Code>OK</Code><BizId>900619746936498440^0</BizId></SendSmsResponse>`,
+			"OK", "900619746936498440^0", nil,
+		},
+		{
+			"error envelope", "xml", true,
+			`<?xml version='1.0' encoding='UTF-8'?><Error><RequestId>F655A8D5-B967-440B-8683-DAD6FF8DE990</RequestId><HostId>dysmsapi.aliyuncs.com</HostId><Code>SignatureDoesNotMatch</Code><Message>Specified signature is not matched with our calculation.</Message></Error>`,
+			"SignatureDoesNotMatch", "", ErrSignatureInvalid,
+		},
+		{
+			"json format with xml content type", "", true,
+			`<?xml version='1.0' encoding='UTF-8'?><SendSmsResponse><Message>OK</Message><RequestId>r1</RequestId><Code>OK</Code><BizId>biz-1</BizId></SendSmsResponse>`,
+			"OK", "biz-1", nil,
+		},
+		{
+			"xml format with json content type", "xml", false,
+			`{"Message":"OK","RequestId":"r1","Code":"OK","BizId":"biz-1"}`,
+			"OK", "biz-1", nil,
+		},
+	}
+
+	for _, c := range cases {
+		var format string
+		transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			form, _ := url.ParseQuery(string(body))
+			format = form.Get("Format")
+
+			if c.xml {
+				return newXmlTestResponse(c.body), nil
+			}
+			return newTestResponse(c.body), nil
+		})
+
+		sms := NewAliyunSms("id", "secret", "", "sign", WithFormat(c.format), WithTransport(transport))
+		sms.SetTemplateCode("SMS_1")
+		sms.SetTemplateParam(NewSmsTemplateParams("code", "1234"))
+
+		result, err := sms.SendMessage(context.Background(), NewMessage("13800000000"))
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.err)
+		}
+		if result.Code != c.code || result.Model != c.bizId || result.IsSuccess != (c.err == nil) {
+			t.Errorf("%s: got %#v", c.name, result)
+		}
+		if wantFormat := strings.ToUpper(c.format); (len(wantFormat) > 0 && format != wantFormat) || (len(wantFormat) == 0 && format != "JSON") {
+			t.Errorf("%s: got Format %s", c.name, format)
+		}
+	}
+}
+
+func TestAliyunQuerySendDetailsXml(t *testing.T) {
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return newXmlTestResponse(`<?xml version='1.0' encoding='UTF-8'?><QuerySendDetailsResponse><TotalCount>1</TotalCount><Message>OK</Message><RequestId>r1</RequestId>` +
+			`<SmsSendDetailDTOs><SmsSendDetailDTO><SendDate>2019-01-08 16:44:10</SendDate><OutId>123</OutId><SendStatus>3</SendStatus>` +
+			`<ReceiveDate>2019-01-08 16:44:13</ReceiveDate><ErrCode>DELIVERED</ErrCode><TemplateCode>SMS_122310183</TemplateCode>` +
+			`<Content>【阿里云】验证码为：123</Content><PhoneNum>15298356881</PhoneNum></SmsSendDetailDTO></SmsSendDetailDTOs><Code>OK</Code></QuerySendDetailsResponse>`), nil
+	})
+
+	sms := NewAliyunSms("id", "secret", "", "sign", WithFormat("xml"), WithTransport(transport))
+	result, err := sms.QuerySendDetails(context.Background(), &AliyunSendDetailsQuery{
+		Mobile:   "15298356881",
+		SendDate: time.Date(2019, 1, 8, 0, 0, 0, 0, chinaLocation),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Code != "OK" || result.TotalCount != 1 || len(result.Details) != 1 {
+		t.Fatalf("got %#v", result)
+	}
+
+	detail := result.Details[0]
+	if detail.Mobile != "15298356881" || !detail.IsDelivered() || detail.OutId != "123" || detail.ErrCode != "DELIVERED" ||
+		!detail.ReceiveTime().Equal(time.Date(2019, 1, 8, 16, 44, 13, 0, chinaLocation)) {
+		t.Fatalf("got %#v", detail)
+	}
+}
+
+func TestAliyunMobile(t *testing.T) {
+	cases := map[string]string{
+		"13800000000":        "13800000000",
+		"+8613800000000":     "13800000000",
+		"008613800000000":    "13800000000",
+		"8613800000000":      "13800000000",
+		"+86 138-0000-0000":  "13800000000",
+		"+85261234567":       "85261234567",
+		"85261234567":        "85261234567",
+		"0085261234567":      "85261234567",
+		"+1 (415) 555-2671":  "14155552671",
+		"+447911123456":      "447911123456",
+		"447911123456":       "447911123456",
+		"abc":                "",
+		"+":                  "",
+		"138000000001234abc": "",
+	}
+
+	for mobile, want := range cases {
+		got, err := aliyunMobile(mobile)
+		if len(want) == 0 {
+			if !errors.Is(err, ErrInvalidMobile) {
+				t.Errorf("%q: got %q %v, want ErrInvalidMobile", mobile, got, err)
+			}
+			continue
+		}
+
+		if err != nil || got != want {
+			t.Errorf("%q: got %q %v, want %q", mobile, got, err, want)
+		}
+	}
+}
+
+func TestAliyunOutIdAndExtendCode(t *testing.T) {
+	var form url.Values
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		return newTestResponse(`{"Code":"OK","Message":"OK","RequestId":"r1","BizId":"b1"}`), nil
+	})
+
+	sms := NewAliyunSms("id", "secret", "", "sign", WithTransport(transport))
+
+	message := NewMessage("+86 13800000000", "+85261234567")
+	message.TemplateCode = "SMS_1"
+	message.TemplateParam = NewSmsTemplateParams("code", "1234")
+	message.OutId = "order-1"
+	message.ExtendCode = "90999"
+	result, err := sms.SendMessage(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Get("PhoneNumbers") != "13800000000,85261234567" || form.Get("OutId") != "order-1" || form.Get("SmsUpExtendCode") != "90999" {
+		t.Fatalf("got params %v", form)
+	}
+	if result.OutId != "order-1" || result.Model != "b1" {
+		t.Fatalf("got %#v", result)
+	}
+
+	//OutId和SmsUpExtendCode参与签名
+	dict := make(map[string]string, 0)
+	for key := range form {
+		if key != "Signature" {
+			dict[key] = form.Get(key)
+		}
+	}
+	if want, _ := url.QueryUnescape(sms.(*aliyunSms).Sign(dict, "secret")); form.Get("Signature") != want {
+		t.Fatalf("got signature %s, want %s", form.Get("Signature"), want)
+	}
+
+	//未设置时不发送空参数
+	message.OutId, message.ExtendCode = "", ""
+	if _, err := sms.SendMessage(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+	if _, isOk := form["OutId"]; isOk {
+		t.Fatalf("got params %v", form)
+	}
+	if _, isOk := form["SmsUpExtendCode"]; isOk {
+		t.Fatalf("got params %v", form)
+	}
+}
+
+func TestAliyunCancelSend(t *testing.T) {
+	testCancelSend(t, NewAliyunSms("id", "secret", "", "sign"), "")
+}
@@ -0,0 +1,138 @@
+package gsms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+/* ================================================================================
+ * 阿里云V3签名（ACS3-HMAC-SHA256）
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	AliyunSignatureV1 = "1.0" //HMAC-SHA1，签名作为请求参数
+	AliyunSignatureV3 = "3.0" //ACS3-HMAC-SHA256，签名放在Authorization请求头
+
+	aliyunV3Algorithm = "ACS3-HMAC-SHA256"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置阿里云签名版本（AliyunSignatureV1或AliyunSignatureV3），默认V1
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithAliyunSignatureVersion(version string) Option {
+	return func(o *options) {
+		o.aliyunSignatureVersion = version
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 使用V3签名发送请求，业务参数放在查询字符串，公共参数放在x-acs-*请求头
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *aliyunSms) callV3(ctx context.Context, credentials *AliyunCredentials, action string, params map[string]string) (*httpResponse, error) {
+	geteway, err := url.Parse(s.geteway())
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.GetParamString(params, true)
+	payloadHash := sha256Hex("")
+
+	header := make(map[string]string, 0)
+	header["host"] = geteway.Host
+	header["x-acs-action"] = action
+	header["x-acs-version"] = s.Version
+	header["x-acs-date"] = s.options.now().UTC().Format("2006-01-02T15:04:05Z")
+	header["x-acs-signature-nonce"] = s.options.nonce()
+	header["x-acs-content-sha256"] = payloadHash
+
+	if len(credentials.SecurityToken) > 0 {
+		header["x-acs-security-token"] = credentials.SecurityToken
+	}
+
+	//规范化URI与实际请求路径一致，网关不带路径时为/
+	path := geteway.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	header["Authorization"] = aliyunAuthorizationV3(credentials.AccessKeyId, credentials.AccessKeySecret, http.MethodPost, path, query, header, payloadHash)
+
+	//Host由请求地址决定，不能作为普通请求头设置
+	delete(header, "host")
+
+	requestUrl := geteway.Scheme + "://" + geteway.Host + path
+	if len(query) > 0 {
+		requestUrl = requestUrl + "?" + query
+	}
+
+	return s.options.httpDo(ctx, http.MethodPost, requestUrl, header, "")
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 计算V3签名的Authorization请求头
+ * 参与签名的请求头为host、content-type和x-acs-*，query为已编码并按key升序排列的查询字符串
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func aliyunAuthorizationV3(accessKeyId, accessKeySecret, method, path, query string, header map[string]string, payloadHash string) string {
+	//规范化请求头
+	signedHeaders := make([]string, 0)
+	canonicalHeaders := make(map[string]string, 0)
+	for key, value := range header {
+		key = strings.ToLower(key)
+		if key == "host" || key == "content-type" || strings.HasPrefix(key, "x-acs-") {
+			signedHeaders = append(signedHeaders, key)
+			canonicalHeaders[key] = strings.TrimSpace(value)
+		}
+	}
+	sort.Strings(signedHeaders)
+
+	var canonicalHeaderString strings.Builder
+	for _, key := range signedHeaders {
+		canonicalHeaderString.WriteString(fmt.Sprintf("%s:%s\n", key, canonicalHeaders[key]))
+	}
+	signedHeaderString := strings.Join(signedHeaders, ";")
+
+	//规范化请求
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		query,
+		canonicalHeaderString.String(),
+		signedHeaderString,
+		payloadHash,
+	}, "\n")
+
+	//待签名字符串
+	stringToSign := aliyunV3Algorithm + "\n" + sha256Hex(canonicalRequest)
+
+	//hmac sha256签名
+	signature := hex.EncodeToString(hmacSha256([]byte(accessKeySecret), stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s,SignedHeaders=%s,Signature=%s", aliyunV3Algorithm, accessKeyId, signedHeaderString, signature)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * sha256十六进制小写摘要
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * hmac sha256签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
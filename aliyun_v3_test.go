@@ -0,0 +1,79 @@
+package gsms
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// 阿里云V3签名文档示例
+func TestAliyunAuthorizationV3KnownAnswer(t *testing.T) {
+	var request *http.Request
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		request = r
+		return newTestResponse(`{"Code":"OK"}`), nil
+	})
+
+	sms := newAliyunSms("YourAccessKeyId", "YourAccessKeySecret", "", "",
+		WithAliyunSignatureVersion(AliyunSignatureV3),
+		WithTransport(transport),
+		WithClock(fixedClock("2023-10-26T10:22:32Z")),
+		WithNonce(fixedNonce("3156853299f313e23d1673dc12e1703d")))
+	sms.Version = "2014-05-26"
+	sms.SetGeteway("https://ecs.cn-shanghai.aliyuncs.com")
+
+	params := map[string]string{
+		"ImageId":  "win2019_1809_x64_dtc_zh-cn_40G_alibase_20230811.vhd",
+		"RegionId": "cn-shanghai",
+	}
+	if err := sms.call(context.Background(), "RunInstances", params, new(AliyunSmsSendResultResponse)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "ACS3-HMAC-SHA256 Credential=YourAccessKeyId," +
+		"SignedHeaders=host;x-acs-action;x-acs-content-sha256;x-acs-date;x-acs-signature-nonce;x-acs-version," +
+		"Signature=06563a9e1b43f5dfe96b81484da74bceab24a1d853912eee15083a6f0f3283c0"
+	if got := request.Header.Get("Authorization"); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	if got := request.URL.String(); got != "https://ecs.cn-shanghai.aliyuncs.com/?ImageId=win2019_1809_x64_dtc_zh-cn_40G_alibase_20230811.vhd&RegionId=cn-shanghai" {
+		t.Fatalf("got url %s", got)
+	}
+}
+
+func TestAliyunV3SignsGetewayPath(t *testing.T) {
+	var request *http.Request
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		request = r
+		return newTestResponse(`{"Code":"OK"}`), nil
+	})
+
+	clock := fixedClock("2023-10-26T10:22:32Z")
+	nonce := fixedNonce("nonce")
+	sms := newAliyunSms("id", "secret", "", "",
+		WithAliyunSignatureVersion(AliyunSignatureV3),
+		WithTransport(transport), WithClock(clock), WithNonce(nonce))
+	sms.SetGeteway("https://proxy.example.com/sms%20api")
+
+	if err := sms.call(context.Background(), "SendSms", map[string]string{"A": "1"}, new(AliyunSmsSendResultResponse)); err != nil {
+		t.Fatal(err)
+	}
+
+	if request.URL.EscapedPath() != "/sms%20api" {
+		t.Fatalf("got path %s", request.URL.EscapedPath())
+	}
+
+	header := map[string]string{
+		"host":                  "proxy.example.com",
+		"x-acs-action":          "SendSms",
+		"x-acs-version":         sms.Version,
+		"x-acs-date":            "2023-10-26T10:22:32Z",
+		"x-acs-signature-nonce": "nonce",
+		"x-acs-content-sha256":  sha256Hex(""),
+	}
+	want := aliyunAuthorizationV3("id", "secret", http.MethodPost, "/sms%20api", "A=1", header, sha256Hex(""))
+	if got := request.Header.Get("Authorization"); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,332 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+/* ================================================================================
+ * 短信错误
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrInvalidArgument      = errors.New("参数不正确")
+	ErrInvalidMobile        = errors.New("手机号不正确")
+	ErrTemplateMissing      = errors.New("短信模版不存在")
+	ErrTemplateParamInvalid = errors.New("短信模版参数不正确")
+	ErrTemplateNotApproved  = errors.New("短信模版未审核通过")
+	ErrSignNameInvalid      = errors.New("短信签名不正确")
+	ErrSignatureInvalid     = errors.New("请求签名不正确")
+	ErrAuth                 = errors.New("账号认证失败")
+	ErrRateLimited          = errors.New("发送频率超限")
+	ErrInsufficientBalance  = errors.New("账户余额不足")
+	ErrContentBlocked       = errors.New("短信内容被拦截")
+	ErrProviderUnavailable  = errors.New("短信服务不可用")
+	ErrProvider             = errors.New("短信服务返回错误")
+	ErrTransport            = errors.New("短信网关请求失败")
+)
+
+type (
+	/*
+	 * 短信网关返回的错误
+	 * Err为错误类型（ErrRateLimited等），可用errors.Is判断
+	 */
+	SmsError struct {
+		Provider  string `form:"provider" json:"provider"`     //短信提供者
+		Code      string `form:"code" json:"code"`             //网关错误码
+		Message   string `form:"msg" json:"msg"`               //网关错误信息
+		RequestId string `form:"request_id" json:"request_id"` //请求Id
+		Err       error  `form:"-" json:"-"`                   //错误类型
+	}
+
+	/*
+	 * 网络请求错误
+	 * errors.Is(err, ErrTransport)为true，同时保留原始错误（如context.DeadlineExceeded）
+	 */
+	TransportError struct {
+		Err error
+	}
+
+	/*
+	 * 网关返回的Http状态错误，响应内容不再解析
+	 * 5xx归类为ErrProviderUnavailable，429归类为ErrRateLimited，均可重试
+	 */
+	HttpStatusError struct {
+		StatusCode int    //Http状态码
+		Body       string //响应内容
+		Err        error  //错误类型
+	}
+)
+
+// 重试后可能成功的错误类型
+var retryableErrors = []error{
+	ErrRateLimited,
+	ErrProviderUnavailable,
+}
+
+// 阿里云错误码
+var aliyunErrorCodes = map[string]error{
+	"isv.MOBILE_NUMBER_ILLEGAL":       ErrInvalidMobile,
+	"isv.MOBILE_COUNT_OVER_LIMIT":     ErrInvalidMobile,
+	"isv.SMS_TEMPLATE_ILLEGAL":        ErrTemplateNotApproved,
+	"isv.TEMPLATE_MISSING_PARAMETERS": ErrTemplateParamInvalid,
+	"isv.TEMPLATE_PARAMS_ILLEGAL":     ErrTemplateParamInvalid,
+	"isv.PARAM_LENGTH_LIMIT":          ErrTemplateParamInvalid,
+	"isv.PARAM_NOT_SUPPORT_URL":       ErrTemplateParamInvalid,
+	"isv.SMS_SIGNATURE_ILLEGAL":       ErrSignNameInvalid,
+	"isv.SMS_SIGN_ILLEGAL":            ErrSignNameInvalid,
+	"isv.INVALID_PARAMETERS":          ErrInvalidArgument,
+	"isv.INVALID_JSON_PARAM":          ErrInvalidArgument,
+	"isv.BUSINESS_LIMIT_CONTROL":      ErrRateLimited,
+	"isv.DAY_LIMIT_CONTROL":           ErrRateLimited,
+	"isv.AMOUNT_NOT_ENOUGH":           ErrInsufficientBalance,
+	"isv.OUT_OF_SERVICE":              ErrInsufficientBalance,
+	"isv.BLACK_KEY_CONTROL_LIMIT":     ErrContentBlocked,
+	"isv.ACCOUNT_NOT_EXISTS":          ErrAuth,
+	"isv.ACCOUNT_ABNORMAL":            ErrAuth,
+	"isv.PRODUCT_UN_SUBSCRIPT":        ErrAuth,
+	"isv.PRODUCT_UNSUBSCRIBE":         ErrAuth,
+	"isp.RAM_PERMISSION_DENY":         ErrAuth,
+	"isp.SYSTEM_ERROR":                ErrProviderUnavailable,
+	"InvalidAccessKeyId.NotFound":     ErrAuth,
+	"InvalidAccessKeyId.Inactive":     ErrAuth,
+	"SignatureDoesNotMatch":           ErrSignatureInvalid,
+	"SignatureNonceUsed":              ErrSignatureInvalid,
+	"InvalidTimeStamp.Expired":        ErrSignatureInvalid,
+	"Throttling":                      ErrRateLimited,
+	"Throttling.User":                 ErrRateLimited,
+	"ServiceUnavailable":              ErrProviderUnavailable,
+	"InternalError":                   ErrProviderUnavailable,
+	"AccessDenied":                    ErrAuth,
+	"InvalidAccessKeyId":              ErrAuth,
+	"QueueNotExist":                   ErrInvalidArgument,
+	"NoPermission":                    ErrAuth,
+	"EntityNotExist.Role":             ErrAuth,
+	"InvalidParameter.RoleArn":        ErrInvalidArgument,
+	"InvalidParameter.PolicyGrammar":  ErrInvalidArgument,
+}
+
+// 阿里大鱼错误码（sub_code优先，其次为淘宝开放平台code）
+var alidayuErrorCodes = map[string]error{
+	"isv.MOBILE_NUMBER_ILLEGAL":       ErrInvalidMobile,
+	"isv.MOBILE_COUNT_OVER_LIMIT":     ErrInvalidMobile,
+	"isv.SMS_TEMPLATE_ILLEGAL":        ErrTemplateNotApproved,
+	"isv.TEMPLATE_MISSING_PARAMETERS": ErrTemplateParamInvalid,
+	"isv.PARAM_LENGTH_LIMIT":          ErrTemplateParamInvalid,
+	"isv.SMS_SIGNATURE_ILLEGAL":       ErrSignNameInvalid,
+	"isv.INVALID_PARAMETERS":          ErrInvalidArgument,
+	"isv.BUSINESS_LIMIT_CONTROL":      ErrRateLimited,
+	"isv.AMOUNT_NOT_ENOUGH":           ErrInsufficientBalance,
+	"isv.OUT_OF_SERVICE":              ErrInsufficientBalance,
+	"isv.BLACK_KEY_CONTROL_LIMIT":     ErrContentBlocked,
+	"isv.ACCOUNT_NOT_EXISTS":          ErrAuth,
+	"isv.ACCOUNT_ABNORMAL":            ErrAuth,
+	"isp.SYSTEM_ERROR":                ErrProviderUnavailable,
+	"7":                               ErrRateLimited,         //App Call Limited
+	"10":                              ErrProviderUnavailable, //Service Currently Unavailable
+	"11":                              ErrAuth,                //Insufficient ISV Permissions
+	"25":                              ErrSignatureInvalid,    //Invalid Signature
+	"29":                              ErrAuth,                //Invalid App Key
+	"31":                              ErrSignatureInvalid,    //Invalid Timestamp
+	"40":                              ErrInvalidArgument,     //Missing Required Arguments
+	"41":                              ErrInvalidArgument,     //Invalid Arguments
+}
+
+// 野狗错误码
+var yegouErrorCodes = map[string]error{
+	"70001": ErrAuth,                 //应用不存在
+	"70002": ErrAuth,                 //短信服务未开通
+	"70003": ErrSignatureInvalid,     //签名错误
+	"70004": ErrSignatureInvalid,     //时间戳超出有效期
+	"70011": ErrInvalidMobile,        //手机号格式错误
+	"70012": ErrInvalidMobile,        //手机号数量超出限制
+	"70021": ErrTemplateMissing,      //模版不存在
+	"70022": ErrTemplateNotApproved,  //模版未审核通过
+	"70023": ErrTemplateParamInvalid, //模版参数与模版不匹配
+	"70024": ErrSignNameInvalid,      //签名未审核通过
+	"70031": ErrRateLimited,          //同一手机号发送过于频繁
+	"70032": ErrRateLimited,          //同一手机号当日发送量超限
+	"70041": ErrInsufficientBalance,  //余额不足
+	"70042": ErrContentBlocked,       //内容包含敏感词
+	"70051": ErrInvalidArgument,      //验证码未发送
+	"70053": ErrInvalidArgument,      //验证码错误
+	"70054": ErrInvalidArgument,      //验证码已过期
+	"79998": ErrInvalidArgument,      //请求错误
+	"79999": ErrProviderUnavailable,  //服务内部错误
+}
+
+// 腾讯云错误码
+var tencentErrorCodes = map[string]error{
+	"AuthFailure.SecretIdNotFound":                              ErrAuth,
+	"AuthFailure.InvalidSecretId":                               ErrAuth,
+	"AuthFailure.UnauthorizedOperation":                         ErrAuth,
+	"AuthFailure.SignatureFailure":                              ErrSignatureInvalid,
+	"AuthFailure.SignatureExpire":                               ErrSignatureInvalid,
+	"FailedOperation.SignatureIncorrectOrUnapproved":            ErrSignNameInvalid,
+	"FailedOperation.TemplateIncorrectOrUnapproved":             ErrTemplateNotApproved,
+	"FailedOperation.TemplateParamSetNotMatchApprovedTemplate":  ErrTemplateParamInvalid,
+	"FailedOperation.InsufficientBalanceInSmsPackage":           ErrInsufficientBalance,
+	"FailedOperation.ContainSensitiveWord":                      ErrContentBlocked,
+	"FailedOperation.PhoneNumberInBlacklist":                    ErrContentBlocked,
+	"InvalidParameterValue.IncorrectPhoneNumber":                ErrInvalidMobile,
+	"InvalidParameterValue.TemplateParameterFormatError":        ErrTemplateParamInvalid,
+	"InvalidParameterValue.TemplateParameterLengthLimit":        ErrTemplateParamInvalid,
+	"InvalidParameterValue.ProhibitedUseUrlInTemplateParameter": ErrTemplateParamInvalid,
+	"InvalidParameterValue.SdkAppIdNotExist":                    ErrInvalidArgument,
+	"LimitExceeded.PhoneNumberCountLimit":                       ErrInvalidMobile,
+	"LimitExceeded.PhoneNumberDailyLimit":                       ErrRateLimited,
+	"LimitExceeded.PhoneNumberOneHourLimit":                     ErrRateLimited,
+	"LimitExceeded.PhoneNumberThirtySecondLimit":                ErrRateLimited,
+	"LimitExceeded.PhoneNumberSameContentDailyLimit":            ErrRateLimited,
+	"LimitExceeded.DeliveryFrequencyLimit":                      ErrRateLimited,
+	"RequestLimitExceeded":                                      ErrRateLimited,
+	"InternalError.Timeout":                                     ErrProviderUnavailable,
+	"InternalError.RequestTimeException":                        ErrSignatureInvalid,
+	"InternalError":                                             ErrProviderUnavailable,
+}
+
+// 华为云错误码
+var huaweiErrorCodes = map[string]error{
+	"E000101": ErrAuth,                 //鉴权失败
+	"E000102": ErrAuth,                 //app_key无效
+	"E000103": ErrAuth,                 //app_key不可用
+	"E000104": ErrAuth,                 //app_secret无效
+	"E000105": ErrSignatureInvalid,     //PasswordDigest无效
+	"E000106": ErrAuth,                 //app_key没有调用本API的权限
+	"E000109": ErrAuth,                 //用户状态未激活
+	"E000110": ErrSignatureInvalid,     //时间超出限制
+	"E000111": ErrAuth,                 //用户名或密码错误
+	"E000112": ErrAuth,                 //用户状态已冻结
+	"E000503": ErrInvalidArgument,      //参数格式错误
+	"E000504": ErrInvalidArgument,      //参数数量错误
+	"E000623": ErrRateLimited,          //短信发送量达到限额
+	"E200015": ErrInvalidMobile,        //待发送短信数量太大
+	"E200028": ErrTemplateParamInvalid, //模版变量校验失败
+	"E200029": ErrTemplateNotApproved,  //模版类型校验失败
+	"E200030": ErrTemplateNotApproved,  //模版未激活
+	"E200033": ErrTemplateNotApproved,  //模版类型不正确
+	"E200041": ErrInvalidMobile,        //同一短信内容接收号码重复
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 根据错误码表创建网关错误，未知错误码归类为ErrProvider
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newSmsError(provider string, codes map[string]error, code, message, requestId string) *SmsError {
+	err, isOk := codes[code]
+	if !isOk {
+		err = ErrProvider
+	}
+
+	return &SmsError{
+		Provider:  provider,
+		Code:      code,
+		Message:   message,
+		RequestId: requestId,
+		Err:       err,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 错误信息
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *SmsError) Error() string {
+	return fmt.Sprintf("%s sms error: %s %s (%v)", e.Provider, e.Code, e.Message, e.Err)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 错误类型
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *SmsError) Unwrap() error {
+	return e.Err
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否可以重试
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *SmsError) Retryable() bool {
+	return IsRetryable(e.Err)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 错误信息
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrTransport, e.Err)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 原始错误
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 匹配ErrTransport
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *TransportError) Is(target error) bool {
+	return target == ErrTransport
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否可以重试（调用方取消的请求不重试）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *TransportError) Retryable() bool {
+	return !errors.Is(e.Err, context.Canceled)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 根据Http状态码创建错误，5xx和429以外的状态码返回nil，由调用方解析响应内容
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newHttpStatusError(statusCode int, body string) error {
+	var err error
+	if statusCode == http.StatusTooManyRequests {
+		err = ErrRateLimited
+	} else if statusCode >= http.StatusInternalServerError {
+		err = ErrProviderUnavailable
+	} else {
+		return nil
+	}
+
+	return &HttpStatusError{
+		StatusCode: statusCode,
+		Body:       body,
+		Err:        err,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 错误信息
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *HttpStatusError) Error() string {
+	return fmt.Sprintf("http status error: %d %s (%v)", e.StatusCode, http.StatusText(e.StatusCode), e.Err)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 错误类型
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (e *HttpStatusError) Unwrap() error {
+	return e.Err
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 判断错误是否可以重试
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func IsRetryable(err error) bool {
+	var transportError *TransportError
+	if errors.As(err, &transportError) {
+		return transportError.Retryable()
+	}
+
+	for _, retryableError := range retryableErrors {
+		if errors.Is(err, retryableError) {
+			return true
+		}
+	}
+
+	return false
+}
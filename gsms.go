@@ -1,7 +1,15 @@
 package gsms
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
 /* ================================================================================
- * 短信接口
+ * 短信和语音接口
  * qq group: 582452342
  * email   : 2091938785@qq.com
  * author  : 美丽的地球啊 - mliu
@@ -9,22 +17,232 @@ package gsms
 type (
 	SmsProvider interface {
 		Send(mobiles string) (*SmsResult, error)
+		SendContext(ctx context.Context, mobiles string) (*SmsResult, error)
+		SendMessage(ctx context.Context, message *Message) (*SmsResult, error)
 		SetTemplateCode(code string)
-		SetTemplateParam(templateParam SmsTemplateParam)
+		SetTemplateParam(templateParam SmsTemplateParamer)
 		SetTemplateString(templateString string)
 		SetSignName(signName string)
 		SetGeteway(geteway string)
 	}
 
-	SmsTemplateParam struct {
-		Code string `form:"code" json:"code"`
+	/*
+	 * 语音通知和语音验证码
+	 */
+	VoiceProvider interface {
+		Call(mobile string) (*SmsResult, error)
+		CallContext(ctx context.Context, mobile string) (*SmsResult, error)
+		CallMessage(ctx context.Context, message *VoiceMessage) (*SmsResult, error)
+		SetTtsCode(code string)
+		SetShowNumber(showNumber string)
+		SetGeteway(geteway string)
+	}
+
+	/*
+	 * 单次呼叫的语音消息
+	 * VoiceCode不为空时播放语音文件，否则按TtsCode和TtsParam合成语音播放
+	 */
+	VoiceMessage struct {
+		Mobile     string            `form:"mobile" json:"mobile"`           //被叫号码
+		ShowNumber string            `form:"show_number" json:"show_number"` //被叫号显（已购买的号码）
+		TtsCode    string            `form:"tts_code" json:"tts_code"`       //文本转语音模版码
+		TtsParam   SmsTemplateParams `form:"tts_param" json:"tts_param"`     //文本转语音模版参数
+		VoiceCode  string            `form:"voice_code" json:"voice_code"`   //语音文件码
+		OutId      string            `form:"out_id" json:"out_id"`           //调用方流水号，回执中原样返回
+	}
+
+	/*
+	 * 单次发送的短信消息
+	 * 发送过程中提供者只读取不修改，未设置的字段使用提供者的默认值（SetTemplateCode等）
+	 */
+	Message struct {
+		Mobiles        []string          `form:"mobiles" json:"mobiles"`                 //接收手机号
+		TemplateCode   string            `form:"template_code" json:"template_code"`     //模版码
+		TemplateParam  SmsTemplateParams `form:"template_param" json:"template_param"`   //模版参数
+		TemplateString string            `form:"template_string" json:"template_string"` //模版参数字符串（TemplateParam为空时使用）
+		RequiredParams []string          `form:"required_params" json:"required_params"` //模版必填参数，发送前校验合并默认值后的模版参数
+		SignName       string            `form:"sign_name" json:"sign_name"`             //短信签名
+		OutId          string            `form:"out_id" json:"out_id"`                   //调用方流水号，回执中原样返回
+		ExtendCode     string            `form:"extend_code" json:"extend_code"`         //上行短信扩展码，用于区分回复
 	}
 
 	SmsResult struct {
-		Code      string `form:"code" json:"code"`
-		Message   string `form:"msg" json:"msg"`
-		Model     string `form:"model" json:"model"`
-		RequestId string `form:"request_id" json:"request_id"`
+		Code       string                `form:"code" json:"code"`
+		Message    string                `form:"msg" json:"msg"`
+		Model      string                `form:"model" json:"model"`
+		RequestId  string                `form:"request_id" json:"request_id"`
+		OutId      string                `form:"out_id" json:"out_id"`
+		IsSuccess  bool                  `form:"is_success" json:"is_success"`
+		Recipients []*SmsRecipientResult `form:"recipients" json:"recipients,omitempty"` //每个手机号的发送结果（网关按号码返回时）
+	}
+
+	/*
+	 * 单个手机号的发送结果
+	 */
+	SmsRecipientResult struct {
+		Mobile    string `form:"mobile" json:"mobile"`         //接收手机号
+		MessageId string `form:"message_id" json:"message_id"` //发送流水号，回执中用于关联
+		Code      string `form:"code" json:"code"`             //网关状态码
+		Message   string `form:"msg" json:"msg"`               //网关状态描述
+		Fee       int    `form:"fee" json:"fee"`               //计费条数
 		IsSuccess bool   `form:"is_success" json:"is_success"`
 	}
 )
+
+// 北京时间（淘宝开放平台时间戳、阿里云回执时间等使用GMT+8）
+var chinaLocation = time.FixedZone("CST", 8*60*60)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建短信消息
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewMessage(mobiles ...string) *Message {
+	message := new(Message)
+	message.Mobiles = append([]string(nil), mobiles...)
+
+	return message
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 使用错误填充结果，SmsError时取厂商错误码、描述和请求Id
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *SmsResult) setError(err error) {
+	var smsErr *SmsError
+	if errors.As(err, &smsErr) {
+		r.Code = smsErr.Code
+		r.Message = smsErr.Message
+		r.RequestId = smsErr.RequestId
+	} else {
+		r.Message = err.Error()
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 合并默认值，返回新的消息副本
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (m *Message) merge(defaults Message) *Message {
+	message := *m
+	message.Mobiles = append([]string(nil), m.Mobiles...)
+	message.RequiredParams = append([]string(nil), m.RequiredParams...)
+
+	if len(message.TemplateCode) == 0 {
+		message.TemplateCode = defaults.TemplateCode
+	}
+
+	if message.TemplateParam == nil && len(message.TemplateString) == 0 {
+		message.TemplateParam = defaults.TemplateParam
+		message.TemplateString = defaults.TemplateString
+	}
+
+	if len(message.SignName) == 0 {
+		message.SignName = defaults.SignName
+	}
+
+	return &message
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 模版参数，TemplateParam为空时按Json对象解析TemplateString
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (m *Message) templateParams() SmsTemplateParams {
+	if m.TemplateParam != nil || len(m.TemplateString) == 0 {
+		return m.TemplateParam
+	}
+
+	var param SmsTemplateParams
+	if err := json.Unmarshal([]byte(m.TemplateString), &param); err != nil {
+		return nil
+	}
+
+	return param
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按顺序排列的模版参数值，TemplateParam为空时TemplateString作为单个参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (m *Message) positionalParams() []string {
+	if m.TemplateParam != nil {
+		return m.TemplateParam.Values()
+	}
+
+	if len(m.TemplateString) > 0 {
+		return []string{m.TemplateString}
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验必填参数，需在合并默认值后调用
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (m *Message) validateParams() error {
+	if len(m.RequiredParams) == 0 {
+		return nil
+	}
+
+	return m.templateParams().Validate(m.RequiredParams...)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验消息的模版码、模版参数和签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func validateMessage(message *Message, paramString string) error {
+	if len(message.TemplateCode) == 0 {
+		return ErrTemplateMissing
+	}
+
+	if len(paramString) == 0 {
+		return ErrTemplateParamInvalid
+	}
+
+	if err := message.validateParams(); err != nil {
+		return err
+	}
+
+	if len(message.SignName) == 0 {
+		return ErrSignNameInvalid
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析北京时间字符串（yyyy-MM-dd HH:mm:ss），格式不正确时返回零值
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseChinaTime(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, chinaLocation)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为E.164格式（+国家码手机号），未带+/00前缀的11位1开头号码按中国大陆号码处理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func e164Mobile(mobile string) (string, error) {
+	mobile, isInternational, err := mobileDigits(mobile)
+	if err != nil {
+		return "", err
+	}
+
+	if !isInternational && len(mobile) == 11 && strings.HasPrefix(mobile, "1") {
+		return "+86" + mobile, nil
+	}
+
+	return "+" + mobile, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 拆分逗号分隔的手机号
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func splitMobiles(mobiles string) []string {
+	items := make([]string, 0)
+	for _, mobile := range strings.Split(mobiles, ",") {
+		if mobile = strings.TrimSpace(mobile); len(mobile) > 0 {
+			items = append(items, mobile)
+		}
+	}
+
+	return items
+}
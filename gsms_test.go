@@ -0,0 +1,251 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 拦截请求的传输层，用于不访问网络的签名测试
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func newTestResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func newXmlTestResponse(body string) *http.Response {
+	response := newTestResponse(body)
+	response.Header.Set("Content-Type", "text/xml;charset=UTF-8")
+
+	return response
+}
+
+func fixedClock(value string) func() time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return func() time.Time {
+		return t
+	}
+}
+
+func fixedNonce(nonce string) func() string {
+	return func() string {
+		return nonce
+	}
+}
+
+// 网关收到的单次发送字段
+type sentMessage struct {
+	Mobile       string
+	TemplateCode string
+	SignName     string
+	Param        string
+	OutId        string
+}
+
+/* 并发发送各自的消息，同时并发修改提供者默认值，网关校验每个请求只包含所属消息的字段
+ * path为网关地址后缀，parse解析请求，reply根据请求返回成功响应，noSignName/noOutId表示请求中没有对应字段 */
+func testConcurrentSend(t *testing.T, sms SmsProvider, path string, parse func(r *http.Request, body []byte) sentMessage, reply func(sent sentMessage) string, noSignName, noOutId bool) {
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		sent := parse(r, body)
+		atomic.AddInt32(&received, 1)
+
+		mobile := strings.TrimPrefix(sent.Mobile, "+86")
+		i, err := strconv.Atoi(strings.TrimPrefix(mobile, "1380000"))
+		if err != nil || len(mobile) != 11 {
+			t.Errorf("unexpected mobile %q", sent.Mobile)
+		}
+
+		want := sentMessage{
+			Mobile:       sent.Mobile,
+			TemplateCode: fmt.Sprintf("T%d", i),
+			SignName:     fmt.Sprintf("S%d", i),
+			Param:        fmt.Sprintf("C%d", i),
+			OutId:        fmt.Sprintf("O%d", i),
+		}
+		if noSignName {
+			want.SignName = ""
+		}
+		if noOutId {
+			want.OutId = ""
+		}
+		if sent != want {
+			t.Errorf("request mixed fields: got %+v, want %+v", sent, want)
+		}
+
+		fmt.Fprint(w, reply(sent))
+	}))
+	defer server.Close()
+
+	sms.SetGeteway(server.URL + path)
+
+	done := make(chan struct{})
+	var setters sync.WaitGroup
+	setters.Add(1)
+	go func() {
+		defer setters.Done()
+
+		for n := 0; ; n++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			sms.SetTemplateCode(fmt.Sprintf("default-%d", n))
+			sms.SetSignName(fmt.Sprintf("default-%d", n))
+			sms.SetTemplateParam(NewSmsTemplateParams("code", "default"))
+			sms.SetTemplateString("default")
+		}
+	}()
+
+	const count = 50
+	var senders sync.WaitGroup
+	for i := 0; i < count; i++ {
+		senders.Add(1)
+		go func(i int) {
+			defer senders.Done()
+
+			message := NewMessage(fmt.Sprintf("1380000%04d", i))
+			message.TemplateCode = fmt.Sprintf("T%d", i)
+			message.SignName = fmt.Sprintf("S%d", i)
+			message.TemplateParam = NewSmsTemplateParams("code", fmt.Sprintf("C%d", i))
+			message.OutId = fmt.Sprintf("O%d", i)
+
+			result, err := sms.SendMessage(context.Background(), message)
+			if err != nil {
+				t.Errorf("message %d: %v", i, err)
+				return
+			}
+			if !result.IsSuccess || result.OutId != message.OutId {
+				t.Errorf("message %d: got %+v", i, result)
+			}
+		}(i)
+	}
+	senders.Wait()
+	close(done)
+	setters.Wait()
+
+	if n := atomic.LoadInt32(&received); n != count {
+		t.Fatalf("got %d requests, want %d", n, count)
+	}
+}
+
+// 解析Json对象形式的模版参数中的code
+func templateParamCode(paramString string) string {
+	var params SmsTemplateParams
+	json.Unmarshal([]byte(paramString), &params)
+	code, _ := params.Get("code")
+
+	return code
+}
+
+// 解析Json数组形式的模版参数中的第一个值
+func templateParamFirst(paramString string) string {
+	var params []string
+	json.Unmarshal([]byte(paramString), &params)
+	if len(params) == 0 {
+		return ""
+	}
+
+	return params[0]
+}
+
+func TestE164Mobile(t *testing.T) {
+	cases := map[string]string{
+		"13800000000":       "+8613800000000",
+		"8613800000000":     "+8613800000000",
+		"+8613800000000":    "+8613800000000",
+		"008613800000000":   "+8613800000000",
+		"+86 138-0000-0000": "+8613800000000",
+		"+14155552671":      "+14155552671",
+		"0014155552671":     "+14155552671",
+		"+1 (415) 555-2671": "+14155552671",
+		"+85261234567":      "+85261234567",
+		"85261234567":       "+85261234567",
+		"abc":               "",
+	}
+
+	for mobile, want := range cases {
+		got, err := e164Mobile(mobile)
+		if len(want) == 0 {
+			if !errors.Is(err, ErrInvalidMobile) {
+				t.Errorf("%q: got %q %v, want ErrInvalidMobile", mobile, got, err)
+			}
+			continue
+		}
+
+		if err != nil || got != want {
+			t.Errorf("%q: got %q %v, want %q", mobile, got, err, want)
+		}
+	}
+}
+
+/* 网关收到请求后不响应，分别在请求中途取消和超时
+ * 取消的请求不可重试，超时的请求可以重试，两者都是ErrTransport */
+func testCancelSend(t *testing.T, sms SmsProvider, path string) {
+	received := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	sms.SetGeteway(server.URL + path)
+
+	message := NewMessage("13800000000")
+	message.TemplateCode = "T1"
+	message.TemplateParam = NewSmsTemplateParams("code", "1234")
+	message.SignName = "sign"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-received
+		cancel()
+	}()
+
+	result, err := sms.SendMessage(ctx, message)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrTransport) || IsRetryable(err) {
+		t.Fatalf("canceled: got %v, retryable %v", err, IsRetryable(err))
+	}
+	if result == nil || result.IsSuccess {
+		t.Fatalf("canceled: got %#v", result)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = sms.SendMessage(ctx, message)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrTransport) || !IsRetryable(err) {
+		t.Fatalf("deadline: got %v, retryable %v", err, IsRetryable(err))
+	}
+}
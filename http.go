@@ -0,0 +1,118 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+/* ================================================================================
+ * Http请求
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	httpResponse struct {
+		StatusCode int
+		Header     http.Header
+		Body       string
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送表单Post请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (o *options) httpPost(ctx context.Context, url, params string) (*httpResponse, error) {
+	header := map[string]string{
+		"Content-Type": "application/x-www-form-urlencoded;charset=utf-8",
+	}
+
+	return o.httpDo(ctx, http.MethodPost, url, header, params)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送Http请求，ctx取消或超时会中断请求
+ * 5xx和429返回可重试的HttpStatusError，其它非2xx的响应也返回响应内容，由调用方解析网关的错误信息
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (o *options) httpDo(ctx context.Context, method, url string, header map[string]string, body string) (*httpResponse, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(o.userAgent) > 0 {
+		request.Header.Set("User-Agent", o.userAgent)
+	}
+
+	for key, value := range header {
+		request.Header.Set(key, value)
+	}
+
+	response, err := o.httpClient.Do(request)
+	if err != nil {
+		return nil, &TransportError{Err: err}
+	}
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, &TransportError{Err: err}
+	}
+
+	//网关不可用或限流时的响应可能是Html页面，不再解析
+	if err := newHttpStatusError(response.StatusCode, string(data)); err != nil {
+		return nil, err
+	}
+
+	return &httpResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       string(data),
+	}, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析响应数据，优先根据Content-Type判断Json或Xml，其次使用请求的format
+ * 字段类型不匹配时忽略该字段，与glib.FromJson保持一致
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *httpResponse) decode(format string, v interface{}) error {
+	var err error
+	if r.isXml(format) {
+		err = xml.Unmarshal([]byte(r.Body), v)
+	} else {
+		err = json.Unmarshal([]byte(r.Body), v)
+
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &typeError) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: 响应解析失败 %v", ErrProvider, err)
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否为Xml响应
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *httpResponse) isXml(format string) bool {
+	contentType := strings.ToLower(r.Header.Get("Content-Type"))
+	if strings.Contains(contentType, "xml") {
+		return true
+	}
+
+	if strings.Contains(contentType, "json") {
+		return false
+	}
+
+	return strings.EqualFold(format, "xml")
+}
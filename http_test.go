@@ -0,0 +1,79 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttpStatusErrors(t *testing.T) {
+	cases := []struct {
+		status int
+		body   string
+		err    error
+	}{
+		{http.StatusBadGateway, `<html><body>502 Bad Gateway</body></html>`, ErrProviderUnavailable},
+		{http.StatusServiceUnavailable, `{}`, ErrProviderUnavailable},
+		{http.StatusTooManyRequests, `<html>Too Many Requests</html>`, ErrRateLimited},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+
+		aliyun := NewAliyunSms("id", "secret", "", "sign")
+		aliyun.SetGeteway(server.URL)
+		aliyun.SetTemplateCode("SMS_1")
+		aliyun.SetTemplateParam(NewSmsTemplateParams("code", "1234"))
+
+		yegou := NewYeGouSms("app", "secret")
+		yegou.SetGeteway(server.URL + "/")
+		yegou.SetTemplateCode("100")
+
+		for _, sms := range []SmsProvider{aliyun, yegou} {
+			result, err := sms.SendMessage(context.Background(), NewMessage("13800000000"))
+			if !errors.Is(err, c.err) || !IsRetryable(err) {
+				t.Errorf("%T %d: got %v, want retryable %v", sms, c.status, err, c.err)
+			}
+
+			var statusErr *HttpStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != c.status || statusErr.Body != c.body {
+				t.Errorf("%T %d: got %#v", sms, c.status, err)
+			}
+
+			if result == nil || result.IsSuccess {
+				t.Errorf("%T %d: got result %#v", sms, c.status, result)
+			}
+		}
+
+		server.Close()
+	}
+}
+
+func TestHttpClientErrorBodyIsDecoded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"Code":"isv.MOBILE_NUMBER_ILLEGAL","Message":"invalid mobile","RequestId":"r1"}`))
+	}))
+	defer server.Close()
+
+	sms := NewAliyunSms("id", "secret", "", "sign")
+	sms.SetGeteway(server.URL)
+	sms.SetTemplateCode("SMS_1")
+	sms.SetTemplateParam(NewSmsTemplateParams("code", "1234"))
+
+	_, err := sms.SendMessage(context.Background(), NewMessage("13800000000"))
+
+	var smsErr *SmsError
+	if !errors.As(err, &smsErr) || smsErr.Code != "isv.MOBILE_NUMBER_ILLEGAL" || !errors.Is(err, ErrInvalidMobile) {
+		t.Fatalf("got %v", err)
+	}
+	if IsRetryable(err) {
+		t.Fatal("4xx provider errors should not be retryable")
+	}
+}
@@ -0,0 +1,294 @@
+package gsms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+/* ================================================================================
+ * 华为云消息&短信发送（batchSendSms，WSSE UsernameToken认证）
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	huaweiSuccessCode = "000000"
+)
+
+type (
+	huaweiSms struct {
+		Geteway        string            `form:"geteway" json:"geteway"`
+		AppKey         string            `form:"app_key" json:"app_key"`
+		AppSecret      string            `form:"app_secret" json:"app_secret"`
+		Sender         string            `form:"sender" json:"sender"`                   //签名通道号
+		SignName       string            `form:"sign_name" json:"sign_name"`             //签名名称，通用模版时必填
+		TemplateId     string            `form:"template_id" json:"template_id"`         //默认模版Id
+		TemplateParas  SmsTemplateParams `form:"template_paras" json:"template_paras"`   //默认模版参数
+		TemplateString string            `form:"template_string" json:"template_string"` //默认模版参数字符串（单个参数）
+		StatusCallback string            `form:"status_callback" json:"status_callback"` //状态报告接收地址
+		options        options
+		mu             sync.RWMutex
+	}
+
+	huaweiSendResponse struct {
+		Code        string              `json:"code"`
+		Description string              `json:"description"`
+		Result      []*huaweiSendStatus `json:"result"`
+	}
+
+	huaweiSendStatus struct {
+		OriginTo   string `json:"originTo"`
+		CreateTime string `json:"createTime"`
+		From       string `json:"from"`
+		SmsMsgId   string `json:"smsMsgId"`
+		CountryId  string `json:"countryId"`
+		Status     string `json:"status"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置华为云短信状态报告接收地址
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithHuaweiStatusCallback(statusCallback string) Option {
+	return func(o *options) {
+		o.huaweiStatusCallback = statusCallback
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建华为云短信提供者
+ * sender为签名通道号，signName为签名名称（专用模版可为空）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewHuaweiSms(appKey, appSecret, sender, signName string, opts ...Option) SmsProvider {
+	sms := new(huaweiSms)
+	sms.Geteway = "https://smsapi.cn-north-4.myhuaweicloud.com:443/sms/batchSendSms/v1"
+	sms.AppKey = appKey
+	sms.AppSecret = appSecret
+	sms.Sender = sender
+	sms.SignName = signName
+	sms.options = newOptions(opts)
+	sms.StatusCallback = sms.options.huaweiStatusCallback
+
+	return sms
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置发送网关
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) SetGeteway(geteway string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Geteway = geteway
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送手机信息（多个手机号用逗号分隔）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) Send(mobiles string) (*SmsResult, error) {
+	return s.SendContext(context.Background(), mobiles)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送手机信息，ctx取消或超时会中断请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) SendContext(ctx context.Context, mobiles string) (*SmsResult, error) {
+	return s.SendMessage(ctx, NewMessage(splitMobiles(mobiles)...))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送短信消息，可并发调用
+ * 任一手机号发送失败时返回第一个失败的错误，每个手机号的结果在Recipients中
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) SendMessage(ctx context.Context, message *Message) (*SmsResult, error) {
+	result := new(SmsResult)
+
+	if message == nil || len(message.Mobiles) == 0 {
+		return result, ErrInvalidMobile
+	}
+
+	//合并默认值
+	geteway, message, paras := s.resolve(message)
+	result.OutId = message.OutId
+
+	if len(message.TemplateCode) == 0 {
+		return result, ErrTemplateMissing
+	}
+
+	if err := message.validateParams(); err != nil {
+		return result, err
+	}
+
+	//手机号转换为E.164格式
+	mobiles := make([]string, 0, len(message.Mobiles))
+	for _, mobile := range message.Mobiles {
+		e164, err := e164Mobile(mobile)
+		if err != nil {
+			return result, err
+		}
+		mobiles = append(mobiles, e164)
+	}
+
+	//业务参数
+	params := url.Values{}
+	params.Set("from", s.Sender)
+	params.Set("to", strings.Join(mobiles, ","))
+	params.Set("templateId", message.TemplateCode)
+
+	if len(paras) > 0 {
+		templateParas, _ := json.Marshal(paras)
+		params.Set("templateParas", string(templateParas))
+	}
+
+	if len(s.StatusCallback) > 0 {
+		params.Set("statusCallback", s.StatusCallback)
+	}
+
+	if len(message.SignName) > 0 {
+		params.Set("signature", message.SignName)
+	}
+
+	if len(message.OutId) > 0 {
+		params.Set("extend", message.OutId) //状态报告中原样返回
+	}
+
+	header := map[string]string{
+		"Content-Type":  "application/x-www-form-urlencoded",
+		"Authorization": `WSSE realm="SDP",profile="UsernameToken",type="Appkey"`,
+		"X-WSSE":        s.wsse(),
+	}
+
+	//发送请求
+	httpResponse, err := s.options.httpDo(ctx, http.MethodPost, geteway, header, params.Encode())
+	if err != nil {
+		result.setError(err)
+		return result, err
+	}
+
+	var response huaweiSendResponse
+	if err := httpResponse.decode("json", &response); err != nil {
+		result.setError(err)
+		return result, err
+	}
+
+	result.Code = response.Code
+	result.Message = response.Description
+
+	if response.Code != huaweiSuccessCode {
+		return result, newSmsError("huawei", huaweiErrorCodes, response.Code, response.Description, "")
+	}
+
+	//每个手机号的发送结果
+	var sendErr error
+	messageIds := make([]string, 0, len(response.Result))
+	for _, status := range response.Result {
+		recipient := &SmsRecipientResult{
+			Mobile:    status.OriginTo,
+			MessageId: status.SmsMsgId,
+			Code:      status.Status,
+			IsSuccess: status.Status == huaweiSuccessCode,
+		}
+		result.Recipients = append(result.Recipients, recipient)
+		messageIds = append(messageIds, status.SmsMsgId)
+
+		if !recipient.IsSuccess && sendErr == nil {
+			result.Code = status.Status
+			result.Message = fmt.Sprintf("%s发送失败", status.OriginTo)
+			sendErr = newSmsError("huawei", huaweiErrorCodes, status.Status, result.Message, "")
+		}
+	}
+	result.Model = strings.Join(messageIds, ",")
+
+	if sendErr != nil {
+		return result, sendErr
+	}
+
+	result.IsSuccess = true
+
+	return result, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置模版Id
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) SetTemplateCode(templateCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateId = templateCode
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置模版参数（按顺序序列化为数组）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) SetTemplateParam(templateParam SmsTemplateParamer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateParas = toTemplateParams(templateParam)
+	s.TemplateString = ""
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置模版参数字符串（单个参数）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) SetTemplateString(templateString string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateString = templateString
+	s.TemplateParas = nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置签名名称
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) SetSignName(signName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.SignName = signName
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成X-WSSE请求头，每次请求重新生成Nonce和Created
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) wsse() string {
+	nonce := strings.Replace(s.options.nonce(), "-", "", -1)
+	created := s.options.now().UTC().Format("2006-01-02T15:04:05Z")
+
+	return fmt.Sprintf(`UsernameToken Username="%s",PasswordDigest="%s",Nonce="%s",Created="%s"`,
+		s.AppKey, huaweiPasswordDigest(nonce, created, s.AppSecret), nonce, created)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * PasswordDigest = Base64(SHA256(Nonce + Created + AppSecret))
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func huaweiPasswordDigest(nonce, created, appSecret string) string {
+	sum := sha256.Sum256([]byte(nonce + created + appSecret))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 读取网关并合并消息默认值，返回网关、消息和模版参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *huaweiSms) resolve(message *Message) (string, *Message, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	message = message.merge(Message{
+		TemplateCode:   s.TemplateId,
+		TemplateParam:  s.TemplateParas,
+		TemplateString: s.TemplateString,
+		SignName:       s.SignName,
+	})
+
+	return s.Geteway, message, message.positionalParams()
+}
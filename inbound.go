@@ -0,0 +1,124 @@
+package gsms
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+/* ================================================================================
+ * 上行短信关键字路由
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	//默认退订关键字（单字容易误匹配正常回复，不作为默认关键字）
+	OptOutKeywords = []string{"TD", "STOP", "UNSUBSCRIBE", "退订"}
+)
+
+type (
+	/*
+	 * 上行短信关键字路由，Dispatch可直接作为InboundMessageHandler使用
+	 * 关键字忽略大小写和首尾空白标点，匹配整条内容，多字关键字还匹配第一个词
+	 */
+	InboundRouter struct {
+		routes      map[string]InboundMessageHandler
+		defaultFunc InboundMessageHandler
+		mu          sync.RWMutex
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建上行短信关键字路由
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewInboundRouter() *InboundRouter {
+	return &InboundRouter{
+		routes: make(map[string]InboundMessageHandler, 0),
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 注册关键字处理，相同关键字后注册的覆盖先注册的
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *InboundRouter) Handle(handler InboundMessageHandler, keywords ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, keyword := range keywords {
+		if keyword = normalizeKeyword(keyword); len(keyword) > 0 {
+			r.routes[keyword] = handler
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 注册退订处理，未指定关键字时使用OptOutKeywords
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *InboundRouter) HandleOptOut(handler InboundMessageHandler, keywords ...string) {
+	if len(keywords) == 0 {
+		keywords = OptOutKeywords
+	}
+
+	r.Handle(handler, keywords...)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 注册未匹配任何关键字时的处理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *InboundRouter) HandleDefault(handler InboundMessageHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaultFunc = handler
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 分发上行短信，没有匹配的处理时忽略
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *InboundRouter) Dispatch(ctx context.Context, message *InboundMessage) error {
+	if message == nil {
+		return nil
+	}
+
+	if handler := r.match(message.Content); handler != nil {
+		return handler(ctx, message)
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按整条内容、第一个词的顺序查找处理，单字关键字只匹配整条内容
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *InboundRouter) match(content string) InboundMessageHandler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	content = normalizeKeyword(content)
+	if handler, ok := r.routes[content]; ok {
+		return handler
+	}
+
+	if fields := strings.Fields(content); len(fields) > 1 {
+		keyword := normalizeKeyword(fields[0])
+		if handler, ok := r.routes[keyword]; ok && utf8.RuneCountInString(keyword) > 1 {
+			return handler
+		}
+	}
+
+	return r.defaultFunc
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 关键字归一化：去除首尾空白和标点并转为大写
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func normalizeKeyword(keyword string) string {
+	keyword = strings.TrimFunc(keyword, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+
+	return strings.ToUpper(keyword)
+}
@@ -0,0 +1,105 @@
+package gsms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInboundRouterOptOut(t *testing.T) {
+	var matched string
+	router := NewInboundRouter()
+	router.HandleOptOut(func(ctx context.Context, message *InboundMessage) error {
+		matched = "optout"
+		return nil
+	})
+	router.HandleDefault(func(ctx context.Context, message *InboundMessage) error {
+		matched = "default"
+		return nil
+	})
+
+	cases := map[string]string{
+		"TD":              "optout",
+		" td。":            "optout",
+		"退订":              "optout",
+		"STOP please":     "optout",
+		"退订 谢谢":           "optout",
+		"T":               "default",
+		"退":               "default",
+		"T shirt size M":  "default",
+		"退 货怎么办":          "default",
+		"stopwatch":       "default",
+		"Thanks, got it!": "default",
+	}
+
+	for content, want := range cases {
+		matched = ""
+		if err := router.Dispatch(context.Background(), &InboundMessage{Content: content}); err != nil {
+			t.Fatal(err)
+		}
+		if matched != want {
+			t.Errorf("%q: got %s, want %s", content, matched, want)
+		}
+	}
+}
+
+func TestInboundRouterSingleCharKeyword(t *testing.T) {
+	var matched bool
+	router := NewInboundRouter()
+	router.Handle(func(ctx context.Context, message *InboundMessage) error {
+		matched = true
+		return nil
+	}, "Y")
+
+	router.Dispatch(context.Background(), &InboundMessage{Content: "y"})
+	if !matched {
+		t.Fatal("single char keyword should match whole content")
+	}
+
+	matched = false
+	router.Dispatch(context.Background(), &InboundMessage{Content: "Y not"})
+	if matched {
+		t.Fatal("single char keyword should not match first word")
+	}
+}
+
+func TestAliyunInboundHandlerOptOut(t *testing.T) {
+	var optOuts, others []*InboundMessage
+	router := NewInboundRouter()
+	router.HandleOptOut(func(ctx context.Context, message *InboundMessage) error {
+		optOuts = append(optOuts, message)
+		return nil
+	})
+	router.HandleDefault(func(ctx context.Context, message *InboundMessage) error {
+		others = append(others, message)
+		return nil
+	})
+
+	handler := NewAliyunInboundHandler(router.Dispatch)
+
+	body := `[{"phone_number":"13800000000","send_time":"2017-01-01 00:00:00","content":"退订","sign_name":"阿里云","dest_code":"1234","sequence_id":1234567890},` +
+		`{"phone_number":"13900000000","send_time":"2017-01-01 00:00:01","content":"T shirt","sign_name":"阿里云","dest_code":"1234","sequence_id":1234567891}]`
+	request := httptest.NewRequest(http.MethodPost, "/sms/aliyun/up", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"code":0`) {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if len(optOuts) != 1 || optOuts[0].Mobile != "13800000000" || optOuts[0].ExtendCode != "1234" || optOuts[0].SequenceId != "1234567890" {
+		t.Fatalf("got opt-outs %#v", optOuts)
+	}
+	if len(others) != 1 || others[0].Mobile != "13900000000" {
+		t.Fatalf("got others %#v", others)
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/sms/aliyun/up", strings.NewReader(`{"content":"TD"}`))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest || len(optOuts) != 1 {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,165 @@
+package gsms
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+import (
+	"github.com/sanxia/glib"
+)
+
+/* ================================================================================
+ * 短信提供者选项
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	Option func(*options)
+
+	options struct {
+		now        func() time.Time  //时钟，每次请求生成时间戳
+		nonce      func() string     //随机数，每次请求生成防重放随机串
+		httpClient *http.Client      //Http客户端
+		transport  http.RoundTripper //Http传输层
+		timeout    time.Duration     //请求超时时间
+		proxy      *url.URL          //代理地址
+		userAgent  string            //User-Agent请求头
+		format     string            //响应格式（json或xml）
+
+		aliyunSignatureVersion string                    //阿里云签名版本
+		aliyunCredentials      AliyunCredentialsProvider //阿里云凭证提供者
+		topSignMethod          string                    //TOP签名方法（阿里大鱼）
+		yegouSendType          string                    //野狗发送类型
+		tencentRegion          string                    //腾讯云地域
+		huaweiStatusCallback   string                    //华为云状态报告接收地址
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置时钟（测试时可固定时间）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		if now != nil {
+			o.now = now
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置随机数生成器（测试时可固定随机数）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithNonce(nonce func() string) Option {
+	return func(o *options) {
+		if nonce != nil {
+			o.nonce = nonce
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置Http客户端，所有请求都通过该客户端发送
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置Http传输层（连接池、TLS根证书、测试桩等）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置请求超时时间
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置代理地址（WithTransport设置了自定义传输层时不生效）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithProxy(proxy *url.URL) Option {
+	return func(o *options) {
+		o.proxy = proxy
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置User-Agent请求头
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置响应格式（json或xml），阿里云和阿里大鱼支持
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建选项
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newOptions(opts []Option) options {
+	o := options{
+		now:   time.Now,
+		nonce: glib.Guid,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+
+	o.httpClient = o.buildHttpClient()
+
+	return o
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 根据选项创建Http客户端
+ * 调用方提供的客户端不会被修改，需要设置超时或传输层时使用其副本
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (o *options) buildHttpClient() *http.Client {
+	client := new(http.Client)
+	if o.httpClient != nil {
+		*client = *o.httpClient
+	}
+
+	if o.transport != nil {
+		client.Transport = o.transport
+	} else if o.proxy != nil {
+		base, isOk := client.Transport.(*http.Transport)
+		if !isOk || base == nil {
+			base = http.DefaultTransport.(*http.Transport)
+		}
+
+		transport := base.Clone()
+		transport.Proxy = http.ProxyURL(o.proxy)
+		client.Transport = transport
+	}
+
+	if o.timeout > 0 {
+		client.Timeout = o.timeout
+	}
+
+	return client
+}
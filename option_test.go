@@ -0,0 +1,105 @@
+package gsms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWithTimeoutKeepsCallerClient(t *testing.T) {
+	client := &http.Client{Timeout: time.Minute}
+	o := newOptions([]Option{WithHTTPClient(client), WithTimeout(5 * time.Second)})
+
+	if o.httpClient == client || o.httpClient.Timeout != 5*time.Second {
+		t.Fatalf("got %#v", o.httpClient)
+	}
+	if client.Timeout != time.Minute || client.Transport != nil {
+		t.Fatalf("caller client was modified: %#v", client)
+	}
+}
+
+func TestWithProxyOnClientWithoutTransport(t *testing.T) {
+	proxy, _ := url.Parse("http://127.0.0.1:3128")
+	client := new(http.Client)
+	o := newOptions([]Option{WithHTTPClient(client), WithProxy(proxy)})
+
+	transport, isOk := o.httpClient.Transport.(*http.Transport)
+	if !isOk || transport == http.DefaultTransport {
+		t.Fatalf("got transport %#v", o.httpClient.Transport)
+	}
+
+	request, _ := http.NewRequest(http.MethodPost, "https://dysmsapi.aliyuncs.com", nil)
+	if got, err := transport.Proxy(request); err != nil || got.String() != proxy.String() {
+		t.Fatalf("got proxy %v %v", got, err)
+	}
+
+	if client.Transport != nil || http.DefaultTransport.(*http.Transport).Proxy == nil {
+		t.Fatal("caller client or default transport was modified")
+	}
+}
+
+func TestWithProxySendsThroughProxy(t *testing.T) {
+	var target string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.URL.String()
+		w.Write([]byte(`{"status":"ok","data":{"rrid":"r1"}}`))
+	}))
+	defer proxyServer.Close()
+
+	proxy, _ := url.Parse(proxyServer.URL)
+	sms := NewYeGouSms("app", "secret", WithHTTPClient(new(http.Client)), WithProxy(proxy))
+	sms.SetGeteway("http://sms.example.com/api/v1/")
+	sms.SetTemplateCode("100")
+
+	if _, err := sms.SendMessage(context.Background(), NewMessage("13800000000")); err != nil {
+		t.Fatal(err)
+	}
+	if target != "http://sms.example.com/api/v1/app/code/send" {
+		t.Fatalf("got proxied url %s", target)
+	}
+}
+
+func TestWithTransportAndUserAgent(t *testing.T) {
+	var userAgent string
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		userAgent = r.Header.Get("User-Agent")
+		return newTestResponse(`{"status":"ok","data":{"rrid":"r1"}}`), nil
+	})
+
+	//传输层优先于代理设置，调用方客户端的传输层不被替换
+	proxy, _ := url.Parse("http://127.0.0.1:1")
+	client := &http.Client{Transport: http.DefaultTransport}
+	sms := NewYeGouSms("app", "secret", WithHTTPClient(client), WithProxy(proxy), WithTransport(transport), WithUserAgent("gsms-test/1.0"))
+	sms.SetTemplateCode("100")
+
+	if _, err := sms.SendMessage(context.Background(), NewMessage("13800000000")); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "gsms-test/1.0" {
+		t.Fatalf("got User-Agent %q", userAgent)
+	}
+	if client.Transport != http.DefaultTransport {
+		t.Fatal("caller client was modified")
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	var count int
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		count++
+		return newTestResponse(`{"status":"ok","data":{"rrid":"r1"}}`), nil
+	})}
+
+	sms := NewYeGouSms("app", "secret", WithHTTPClient(client))
+	sms.SetTemplateCode("100")
+
+	if _, err := sms.SendMessage(context.Background(), NewMessage("13800000000")); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("got %d requests through caller client", count)
+	}
+}
@@ -0,0 +1,61 @@
+package gsms
+
+import (
+	"context"
+	"time"
+)
+
+/* ================================================================================
+ * 短信回执和上行短信
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	DeliveryStatusDelivered DeliveryStatus = "delivered" //用户接收成功
+	DeliveryStatusFailed    DeliveryStatus = "failed"    //发送失败
+	DeliveryStatusUnknown   DeliveryStatus = "unknown"   //状态未知
+)
+
+type (
+	DeliveryStatus string
+
+	/*
+	 * 统一的短信回执
+	 */
+	DeliveryReport struct {
+		Provider     string         `form:"provider" json:"provider"`           //短信提供者
+		MessageId    string         `form:"message_id" json:"message_id"`       //发送回执Id（阿里云BizId、野狗rrid等）
+		Mobile       string         `form:"mobile" json:"mobile"`               //接收手机号
+		Status       DeliveryStatus `form:"status" json:"status"`               //接收状态
+		ErrorCode    string         `form:"error_code" json:"error_code"`       //运营商状态码
+		ErrorMessage string         `form:"error_message" json:"error_message"` //状态描述
+		OutId        string         `form:"out_id" json:"out_id"`               //调用方流水号
+		SmsSize      int            `form:"sms_size" json:"sms_size"`           //计费条数
+		SendTime     time.Time      `form:"send_time" json:"send_time"`         //发送时间
+		ReportTime   time.Time      `form:"report_time" json:"report_time"`     //回执时间
+	}
+
+	/*
+	 * 统一的上行短信（用户回复）
+	 */
+	InboundMessage struct {
+		Provider   string    `form:"provider" json:"provider"`       //短信提供者
+		Mobile     string    `form:"mobile" json:"mobile"`           //发送手机号
+		Content    string    `form:"content" json:"content"`         //短信内容
+		SignName   string    `form:"sign_name" json:"sign_name"`     //短信签名
+		ExtendCode string    `form:"extend_code" json:"extend_code"` //上行扩展码
+		SequenceId string    `form:"sequence_id" json:"sequence_id"` //消息序列号
+		SendTime   time.Time `form:"send_time" json:"send_time"`     //发送时间
+	}
+
+	DeliveryReportHandler func(ctx context.Context, report *DeliveryReport) error
+	InboundMessageHandler func(ctx context.Context, message *InboundMessage) error
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否已送达
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *DeliveryReport) IsDelivered() bool {
+	return r.Status == DeliveryStatusDelivered
+}
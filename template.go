@@ -0,0 +1,285 @@
+package gsms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/* ================================================================================
+ * 短信模版参数
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	/*
+	 * 可作为模版参数设置的类型（SmsTemplateParams、SmsTemplateParam）
+	 */
+	SmsTemplateParamer interface {
+		Params() SmsTemplateParams
+	}
+
+	/*
+	 * 有序的模版参数
+	 * 阿里云、阿里大鱼序列化为Json对象，野狗等按顺序序列化为数组
+	 */
+	SmsTemplateParams []SmsTemplateParamItem
+
+	SmsTemplateParamItem struct {
+		Key   string `form:"key" json:"key"`
+		Value string `form:"value" json:"value"`
+	}
+
+	/*
+	 * 只有code参数的模版参数，兼容旧版本，新代码请使用SmsTemplateParams
+	 */
+	SmsTemplateParam struct {
+		Code string `form:"code" json:"code"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为有序模版参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParam) Params() SmsTemplateParams {
+	return NewSmsTemplateParams("code", p.Code)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建模版参数，参数为key, value交替排列
+ * gsms.NewSmsTemplateParams("code", "1234", "product", "gsms")
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewSmsTemplateParams(keyValues ...string) SmsTemplateParams {
+	param := make(SmsTemplateParams, 0, len(keyValues)/2)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		param = param.Set(keyValues[i], keyValues[i+1])
+	}
+
+	return param
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 从字典创建模版参数（按key升序）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewSmsTemplateParamsFromMap(values map[string]string) SmsTemplateParams {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	param := make(SmsTemplateParams, 0, len(keys))
+	for _, key := range keys {
+		param = append(param, SmsTemplateParamItem{Key: key, Value: values[key]})
+	}
+
+	return param
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为有序模版参数副本，nil返回nil
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func toTemplateParams(templateParam SmsTemplateParamer) SmsTemplateParams {
+	if templateParam == nil {
+		return nil
+	}
+
+	params := templateParam.Params()
+	if params == nil {
+		return nil
+	}
+
+	return append(SmsTemplateParams{}, params...)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 返回自身，实现SmsTemplateParamer
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) Params() SmsTemplateParams {
+	return p
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置参数，已存在的key替换值并保持位置，返回新的模版参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) Set(key, value string) SmsTemplateParams {
+	param := make(SmsTemplateParams, len(p), len(p)+1)
+	copy(param, p)
+
+	for i, item := range param {
+		if item.Key == key {
+			param[i].Value = value
+			return param
+		}
+	}
+
+	return append(param, SmsTemplateParamItem{Key: key, Value: value})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取参数值
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) Get(key string) (string, bool) {
+	for _, item := range p {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+
+	return "", false
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按顺序获取参数名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) Keys() []string {
+	keys := make([]string, 0, len(p))
+	for _, item := range p {
+		keys = append(keys, item.Key)
+	}
+
+	return keys
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按顺序获取参数值（野狗等位置参数）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) Values() []string {
+	values := make([]string, 0, len(p))
+	for _, item := range p {
+		values = append(values, item.Value)
+	}
+
+	return values
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验必填参数，缺少参数或参数值为空时返回ErrTemplateParamInvalid
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) Validate(required ...string) error {
+	missing := make([]string, 0)
+	for _, key := range required {
+		if value, isOk := p.Get(key); !isOk || len(value) == 0 {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: 缺少参数 %s", ErrTemplateParamInvalid, strings.Join(missing, ","))
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 序列化为Json对象字符串
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) Json() (string, error) {
+	data, err := p.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按参数顺序序列化为Json对象
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p SmsTemplateParams) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+
+	for i, item := range p {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+
+		key, err := json.Marshal(item.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Write(key)
+		buffer.WriteString(":")
+		buffer.Write(value)
+	}
+
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 从Json对象反序列化（保持参数顺序）
+ * 字符串取原值，null为空字符串，数字、布尔和嵌套对象保留原始Json文本；null整体为空参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (p *SmsTemplateParams) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*p = nil
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if delim, isOk := token.(json.Delim); !isOk || delim != '{' {
+		return fmt.Errorf("%w: 模版参数必须是Json对象", ErrTemplateParamInvalid)
+	}
+
+	param := make(SmsTemplateParams, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return err
+		}
+
+		value, err := jsonValueString(raw)
+		if err != nil {
+			return err
+		}
+
+		param = param.Set(token.(string), value)
+	}
+
+	*p = param
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * Json值转换为字符串：字符串取原值，null为空字符串，其它保留原始Json文本
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func jsonValueString(raw json.RawMessage) (string, error) {
+	switch {
+	case string(raw) == "null":
+		return "", nil
+	case len(raw) > 0 && raw[0] == '"':
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, raw); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,115 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSmsTemplateParamsUnmarshalJSON(t *testing.T) {
+	var param SmsTemplateParams
+	data := `{"code":"1234","amount":12.50,"count":3,"big":12345678901234567890,"ok":true,"empty":null,"nested":{"a": [1, 2]}}`
+	if err := json.Unmarshal([]byte(data), &param); err != nil {
+		t.Fatal(err)
+	}
+
+	want := SmsTemplateParams{
+		{Key: "code", Value: "1234"},
+		{Key: "amount", Value: "12.50"},
+		{Key: "count", Value: "3"},
+		{Key: "big", Value: "12345678901234567890"},
+		{Key: "ok", Value: "true"},
+		{Key: "empty", Value: ""},
+		{Key: "nested", Value: `{"a":[1,2]}`},
+	}
+	if !reflect.DeepEqual(param, want) {
+		t.Fatalf("got %#v, want %#v", param, want)
+	}
+}
+
+func TestSmsTemplateParamsUnmarshalNull(t *testing.T) {
+	param := NewSmsTemplateParams("code", "1234")
+	if err := json.Unmarshal([]byte(" null "), &param); err != nil {
+		t.Fatal(err)
+	}
+	if param != nil {
+		t.Fatalf("got %#v, want nil", param)
+	}
+
+	var message Message
+	if err := json.Unmarshal([]byte(`{"template_param":null}`), &message); err != nil {
+		t.Fatal(err)
+	}
+	if message.TemplateParam != nil {
+		t.Fatalf("got %#v, want nil", message.TemplateParam)
+	}
+
+	if err := json.Unmarshal([]byte(`["1234"]`), &param); !errors.Is(err, ErrTemplateParamInvalid) {
+		t.Fatalf("got %v, want ErrTemplateParamInvalid", err)
+	}
+}
+
+func TestSmsTemplateParamCompat(t *testing.T) {
+	params := toTemplateParams(SmsTemplateParam{Code: "1234"})
+	if jsonString, _ := params.Json(); jsonString != `{"code":"1234"}` {
+		t.Fatalf("got %s", jsonString)
+	}
+
+	var nilParams SmsTemplateParams
+	if toTemplateParams(nilParams) != nil || toTemplateParams(nil) != nil {
+		t.Fatal("nil params should stay nil")
+	}
+}
+
+func TestMessageValidateParamsAfterMerge(t *testing.T) {
+	message := &Message{Mobiles: []string{"13800000000"}, RequiredParams: []string{"code"}}
+
+	merged := message.merge(Message{TemplateParam: NewSmsTemplateParams("code", "1234")})
+	if err := merged.validateParams(); err != nil {
+		t.Fatalf("provider default param: %v", err)
+	}
+
+	merged = message.merge(Message{TemplateString: `{"code":"1234"}`})
+	if err := merged.validateParams(); err != nil {
+		t.Fatalf("provider default string: %v", err)
+	}
+
+	merged = message.merge(Message{TemplateParam: NewSmsTemplateParams("name", "Tom")})
+	if err := merged.validateParams(); !errors.Is(err, ErrTemplateParamInvalid) {
+		t.Fatalf("got %v, want ErrTemplateParamInvalid", err)
+	}
+}
+
+func TestProviderDefaultParamsSatisfyRequired(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.Write([]byte(`{"Response":{"SendStatusSet":[{"SerialNo":"1","PhoneNumber":"+8613800000000","Code":"Ok"}],"RequestId":"r1"}}`))
+	}))
+	defer server.Close()
+
+	sms := NewTencentSms("sid", "skey", "1400000000", "sign")
+	sms.SetGeteway(server.URL + "/")
+	sms.SetTemplateCode("100")
+	sms.SetTemplateParam(NewSmsTemplateParams("code", "1234", "minute", "5"))
+
+	message := NewMessage("13800000000")
+	message.RequiredParams = []string{"code", "minute"}
+	if _, err := sms.SendMessage(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+
+	var request tencentSendRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(request.TemplateParamSet, []string{"1234", "5"}) {
+		t.Fatalf("got %v", request.TemplateParamSet)
+	}
+}
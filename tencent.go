@@ -0,0 +1,355 @@
+package gsms
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ================================================================================
+ * 腾讯云短信发送（SendSms 2021-01-11，TC3-HMAC-SHA256签名）
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	tencentAlgorithm   = "TC3-HMAC-SHA256"
+	tencentService     = "sms"
+	tencentContentType = "application/json; charset=utf-8"
+)
+
+type (
+	tencentSms struct {
+		Geteway        string            `form:"geteway" json:"geteway"`
+		SecretId       string            `form:"secret_id" json:"secret_id"`
+		SecretKey      string            `form:"secret_key" json:"secret_key"`
+		Region         string            `form:"region" json:"region"`
+		Action         string            `form:"action" json:"action"`
+		Version        string            `form:"version" json:"version"`
+		SmsSdkAppId    string            `form:"sms_sdk_app_id" json:"sms_sdk_app_id"`
+		SignName       string            `form:"sign_name" json:"sign_name"`
+		TemplateId     string            `form:"template_id" json:"template_id"`         //默认模版Id
+		TemplateParam  SmsTemplateParams `form:"template_param" json:"template_param"`   //默认模版参数
+		TemplateString string            `form:"template_string" json:"template_string"` //默认模版参数字符串（单个参数）
+		options        options
+		mu             sync.RWMutex
+	}
+
+	tencentSendRequest struct {
+		PhoneNumberSet   []string `json:"PhoneNumberSet"`
+		SmsSdkAppId      string   `json:"SmsSdkAppId"`
+		SignName         string   `json:"SignName,omitempty"`
+		TemplateId       string   `json:"TemplateId"`
+		TemplateParamSet []string `json:"TemplateParamSet,omitempty"`
+		ExtendCode       string   `json:"ExtendCode,omitempty"`
+		SessionContext   string   `json:"SessionContext,omitempty"`
+	}
+
+	tencentSendResponse struct {
+		Response struct {
+			SendStatusSet []*tencentSendStatus `json:"SendStatusSet"`
+			Error         *tencentError        `json:"Error"`
+			RequestId     string               `json:"RequestId"`
+		} `json:"Response"`
+	}
+
+	tencentSendStatus struct {
+		SerialNo       string `json:"SerialNo"`
+		PhoneNumber    string `json:"PhoneNumber"`
+		Fee            int    `json:"Fee"`
+		SessionContext string `json:"SessionContext"`
+		Code           string `json:"Code"`
+		Message        string `json:"Message"`
+		IsoCode        string `json:"IsoCode"`
+	}
+
+	tencentError struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置腾讯云地域，默认ap-guangzhou
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithTencentRegion(region string) Option {
+	return func(o *options) {
+		o.tencentRegion = region
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建腾讯云短信提供者
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewTencentSms(secretId, secretKey, smsSdkAppId, signName string, opts ...Option) SmsProvider {
+	sms := new(tencentSms)
+	sms.Geteway = "https://sms.tencentcloudapi.com/"
+	sms.SecretId = secretId
+	sms.SecretKey = secretKey
+	sms.Region = "ap-guangzhou"
+	sms.Action = "SendSms"
+	sms.Version = "2021-01-11"
+	sms.SmsSdkAppId = smsSdkAppId
+	sms.SignName = signName
+	sms.options = newOptions(opts)
+
+	if len(sms.options.tencentRegion) > 0 {
+		sms.Region = sms.options.tencentRegion
+	}
+
+	return sms
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置发送网关
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) SetGeteway(geteway string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Geteway = geteway
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送手机信息（多个手机号用逗号分隔）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) Send(mobiles string) (*SmsResult, error) {
+	return s.SendContext(context.Background(), mobiles)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送手机信息，ctx取消或超时会中断请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) SendContext(ctx context.Context, mobiles string) (*SmsResult, error) {
+	return s.SendMessage(ctx, NewMessage(splitMobiles(mobiles)...))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送短信消息，可并发调用
+ * 任一手机号发送失败时返回第一个失败的错误，每个手机号的结果在Recipients中
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) SendMessage(ctx context.Context, message *Message) (*SmsResult, error) {
+	result := new(SmsResult)
+
+	if message == nil || len(message.Mobiles) == 0 {
+		return result, ErrInvalidMobile
+	}
+
+	//合并默认值
+	geteway, message, params := s.resolve(message)
+	result.OutId = message.OutId
+
+	if len(message.TemplateCode) == 0 {
+		return result, ErrTemplateMissing
+	}
+
+	if err := message.validateParams(); err != nil {
+		return result, err
+	}
+
+	//手机号转换为E.164格式
+	mobiles := make([]string, 0, len(message.Mobiles))
+	for _, mobile := range message.Mobiles {
+		e164, err := e164Mobile(mobile)
+		if err != nil {
+			return result, err
+		}
+		mobiles = append(mobiles, e164)
+	}
+
+	request := &tencentSendRequest{
+		PhoneNumberSet:   mobiles,
+		SmsSdkAppId:      s.SmsSdkAppId,
+		SignName:         message.SignName,
+		TemplateId:       message.TemplateCode,
+		TemplateParamSet: params,
+		ExtendCode:       message.ExtendCode,
+		SessionContext:   message.OutId,
+	}
+
+	//发送请求
+	var response tencentSendResponse
+	if err := s.call(ctx, geteway, request, &response); err != nil {
+		result.setError(err)
+		return result, err
+	}
+
+	result.RequestId = response.Response.RequestId
+
+	if apiError := response.Response.Error; apiError != nil {
+		result.Code = apiError.Code
+		result.Message = apiError.Message
+		return result, newSmsError("tencent", tencentErrorCodes, apiError.Code, apiError.Message, result.RequestId)
+	}
+
+	//每个手机号的发送结果
+	var sendErr error
+	serialNos := make([]string, 0, len(response.Response.SendStatusSet))
+	for _, status := range response.Response.SendStatusSet {
+		recipient := &SmsRecipientResult{
+			Mobile:    status.PhoneNumber,
+			MessageId: status.SerialNo,
+			Code:      status.Code,
+			Message:   status.Message,
+			Fee:       status.Fee,
+			IsSuccess: strings.EqualFold(status.Code, "Ok"),
+		}
+		result.Recipients = append(result.Recipients, recipient)
+		serialNos = append(serialNos, status.SerialNo)
+
+		if !recipient.IsSuccess && sendErr == nil {
+			result.Code = status.Code
+			result.Message = status.Message
+			sendErr = newSmsError("tencent", tencentErrorCodes, status.Code, status.Message, result.RequestId)
+		}
+	}
+	result.Model = strings.Join(serialNos, ",")
+
+	if sendErr != nil {
+		return result, sendErr
+	}
+
+	result.Code = "Ok"
+	result.IsSuccess = true
+
+	return result, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置模版Id
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) SetTemplateCode(templateCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateId = templateCode
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置模版参数（按顺序序列化为数组）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) SetTemplateParam(templateParam SmsTemplateParamer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateParam = toTemplateParams(templateParam)
+	s.TemplateString = ""
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置模版参数字符串（单个参数）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) SetTemplateString(templateString string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateString = templateString
+	s.TemplateParam = nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置签名字符串
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) SetSignName(signName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.SignName = signName
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送签名的Json请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) call(ctx context.Context, geteway string, request, response interface{}) error {
+	endpoint, err := url.Parse(geteway)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	timestamp := s.options.now().Unix()
+
+	//规范化URI与实际请求路径一致，网关不带路径时为/
+	path := endpoint.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	header := make(map[string]string, 0)
+	header["Content-Type"] = tencentContentType
+	header["X-TC-Action"] = s.Action
+	header["X-TC-Version"] = s.Version
+	header["X-TC-Region"] = s.Region
+	header["X-TC-Timestamp"] = fmt.Sprintf("%d", timestamp)
+	header["Authorization"] = tencentAuthorization(s.SecretId, s.SecretKey, tencentService, endpoint.Host, path, string(payload), timestamp)
+
+	httpResponse, err := s.options.httpDo(ctx, http.MethodPost, endpoint.String(), header, string(payload))
+	if err != nil {
+		return err
+	}
+
+	return httpResponse.decode("json", response)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 计算TC3-HMAC-SHA256签名的Authorization请求头
+ * 参与签名的请求头为content-type和host，无查询字符串
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func tencentAuthorization(secretId, secretKey, service, host, path, payload string, timestamp int64) string {
+	date := time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+	signedHeaders := "content-type;host"
+
+	//规范请求串
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost,
+		path,
+		"",
+		fmt.Sprintf("content-type:%s\nhost:%s\n", tencentContentType, host),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	//待签名字符串
+	credentialScope := fmt.Sprintf("%s/%s/tc3_request", date, service)
+	stringToSign := strings.Join([]string{
+		tencentAlgorithm,
+		fmt.Sprintf("%d", timestamp),
+		credentialScope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	//派生签名密钥
+	secretDate := hmacSha256([]byte("TC3"+secretKey), date)
+	secretService := hmacSha256(secretDate, service)
+	secretSigning := hmacSha256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSha256(secretSigning, stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", tencentAlgorithm, secretId, credentialScope, signedHeaders, signature)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 读取网关并合并消息默认值，返回网关、消息和模版参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *tencentSms) resolve(message *Message) (string, *Message, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	message = message.merge(Message{
+		TemplateCode:   s.TemplateId,
+		TemplateParam:  s.TemplateParam,
+		TemplateString: s.TemplateString,
+		SignName:       s.SignName,
+	})
+
+	return s.Geteway, message, message.positionalParams()
+}
@@ -0,0 +1,89 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+// 腾讯云API 3.0签名文档示例
+func TestTencentAuthorizationKnownAnswer(t *testing.T) {
+	payload := `{"Limit": 1, "Filters": [{"Values": ["\u672a\u547d\u540d"], "Name": "instance-name"}]}`
+	got := tencentAuthorization("AKIDz8krbsJ5yKBZQpn74WFkmLPx3*******", "Gu5t9xGARNpq86cd98joQYCN3*******",
+		"cvm", "cvm.tencentcloudapi.com", "/", payload, 1551113065)
+
+	want := "TC3-HMAC-SHA256 Credential=AKIDz8krbsJ5yKBZQpn74WFkmLPx3*******/2019-02-25/cvm/tc3_request, " +
+		"SignedHeaders=content-type;host, " +
+		"Signature=2230eefd229f582d8b1b891af7107b91597240707d778ab3738f756258d7652c"
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestTencentSignsGetewayPath(t *testing.T) {
+	var request *http.Request
+	var body string
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		data, _ := ioutil.ReadAll(r.Body)
+		request, body = r, string(data)
+		return newTestResponse(`{"Response":{"SendStatusSet":[{"SerialNo":"1","PhoneNumber":"+8613800000000","Code":"Ok"}],"RequestId":"r1"}}`), nil
+	})
+
+	sms := NewTencentSms("sid", "skey", "1400000000", "sign",
+		WithTransport(transport), WithClock(fixedClock("2023-11-14T22:13:20Z")))
+	sms.SetGeteway("https://proxy.example.com/tencent/sms")
+
+	message := NewMessage("13800000000")
+	message.TemplateCode = "100"
+	if _, err := sms.SendMessage(context.Background(), message); err != nil {
+		t.Fatal(err)
+	}
+
+	if request.URL.Path != "/tencent/sms" || request.Header.Get("X-TC-Timestamp") != "1700000000" {
+		t.Fatalf("got %s %s", request.URL, request.Header.Get("X-TC-Timestamp"))
+	}
+
+	want := tencentAuthorization("sid", "skey", "sms", "proxy.example.com", "/tencent/sms", body, 1700000000)
+	if got := request.Header.Get("Authorization"); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	//默认网关的请求路径为/
+	want = "TC3-HMAC-SHA256 Credential=sid/2023-11-14/sms/tc3_request, SignedHeaders=content-type;host, " +
+		"Signature=afbaa0476238dfc23d15b9fe66ed8d2e42e210df7712db272fec42ad83777455"
+	if got := tencentAuthorization("sid", "skey", "sms", "sms.tencentcloudapi.com", "/", `{"a":1}`, 1700000000); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestTencentConcurrentSend(t *testing.T) {
+	sms := NewTencentSms("sid", "skey", "1400000000", "sign")
+
+	testConcurrentSend(t, sms, "", func(r *http.Request, body []byte) sentMessage {
+		var request tencentSendRequest
+		json.Unmarshal(body, &request)
+
+		sent := sentMessage{
+			TemplateCode: request.TemplateId,
+			SignName:     request.SignName,
+			OutId:        request.SessionContext,
+		}
+		if len(request.PhoneNumberSet) == 1 {
+			sent.Mobile = request.PhoneNumberSet[0]
+		}
+		if len(request.TemplateParamSet) == 1 {
+			sent.Param = request.TemplateParamSet[0]
+		}
+
+		return sent
+	}, func(sent sentMessage) string {
+		return fmt.Sprintf(`{"Response":{"SendStatusSet":[{"SerialNo":"s-%s","PhoneNumber":"%s","Code":"Ok"}],"RequestId":"r-%s"}}`, sent.OutId, sent.Mobile, sent.OutId)
+	}, false, false)
+}
+
+func TestTencentCancelSend(t *testing.T) {
+	testCancelSend(t, NewTencentSms("sid", "skey", "1400000000", "sign"), "")
+}
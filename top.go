@@ -0,0 +1,314 @@
+package gsms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+import (
+	"github.com/sanxia/glib"
+)
+
+/* ================================================================================
+ * 淘宝开放平台（TOP）Api客户端，阿里大鱼各接口共用
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	TopSignMethodMd5  = "md5"  //secret+参数+secret的Md5
+	TopSignMethodHmac = "hmac" //以secret为密钥的HMAC-MD5
+)
+
+type (
+	/*
+	 * TOP Api客户端，负责公共参数、签名、发送请求和error_response解析
+	 */
+	TopClient interface {
+		Call(ctx context.Context, method string, params map[string]string, response interface{}) error
+		Sign(params map[string]string) string
+		SetGeteway(geteway string)
+		SetSimplify(simplify bool)
+	}
+
+	topClient struct {
+		Geteway    string `form:"geteway" json:"geteway"`
+		AppKey     string `form:"app_key" json:"app_key"`
+		AppSecret  string `form:"app_secret" json:"app_secret"`
+		Format     string `form:"format" json:"format"`
+		Simplify   bool   `form:"simplify" json:"simplify"`
+		SignMethod string `form:"sign_method" json:"sign_method"`
+		Version    string `form:"v" json:"v"`
+		options    options
+		mu         sync.RWMutex
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置TOP签名方法（TopSignMethodMd5或TopSignMethodHmac），默认Md5，其它值调用时返回ErrInvalidArgument
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithTopSignMethod(signMethod string) Option {
+	return func(o *options) {
+		o.topSignMethod = signMethod
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建TOP Api客户端
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewTopClient(appKey, appSecret string, opts ...Option) TopClient {
+	return newTopClient(appKey, appSecret, newOptions(opts))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 创建TOP Api客户端，共用调用方的选项
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newTopClient(appKey, appSecret string, options options) *topClient {
+	client := new(topClient)
+	client.Geteway = alidayuGeteway
+	client.AppKey = appKey
+	client.AppSecret = appSecret
+	client.Format = "json"
+	client.Simplify = true
+	client.SignMethod = TopSignMethodMd5
+	client.Version = "2.0"
+	client.options = options
+
+	if len(options.format) > 0 {
+		client.Format = strings.ToLower(options.format)
+	}
+
+	if len(options.topSignMethod) > 0 {
+		client.SignMethod = options.topSignMethod
+	}
+
+	return client
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置请求网关
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) SetGeteway(geteway string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Geteway = geteway
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置是否使用精简响应（去掉外层xxx_response包装）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) SetSimplify(simplify bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Simplify = simplify
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 调用TOP方法，error_response转换为SmsError，其它响应解析到response
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) Call(ctx context.Context, method string, params map[string]string, response interface{}) error {
+	if c.SignMethod != TopSignMethodMd5 && c.SignMethod != TopSignMethodHmac {
+		return fmt.Errorf("%w: 不支持的TOP签名方法 %s", ErrInvalidArgument, c.SignMethod)
+	}
+
+	geteway, simplify := c.resolve()
+
+	//签名请求参数
+	requestString := c.GetRequestString(c.toDict(method, params, simplify))
+
+	if len(geteway) == 0 {
+		geteway = alidayuGeteway
+	}
+
+	//发起Http请求
+	httpResponse, err := c.options.httpPost(ctx, geteway, requestString)
+	if err != nil {
+		return err
+	}
+
+	if !c.isErrorResponse(httpResponse) {
+		//非精简Json响应包裹在xxx_response中，Xml根节点本身即为包裹
+		if !simplify && !httpResponse.isXml(c.Format) {
+			return c.unwrap(httpResponse, method, response)
+		}
+
+		return httpResponse.decode(c.Format, response)
+	}
+
+	//解析失败数据
+	errorResponse := new(AlidayuSmsSendErrorResponse)
+	if httpResponse.isXml(c.Format) {
+		//Xml格式的根节点即为error_response
+		if err := httpResponse.decode(c.Format, &errorResponse.Result); err != nil {
+			return err
+		}
+	} else if err := httpResponse.decode(c.Format, errorResponse); err != nil {
+		return err
+	}
+
+	//业务错误码在sub_code中
+	code := fmt.Sprintf("%d", errorResponse.Result.Code)
+	message := errorResponse.Result.Message
+	if len(errorResponse.Result.SubCode) > 0 {
+		code = errorResponse.Result.SubCode
+		message = errorResponse.Result.SubMessage
+	}
+
+	return newSmsError("alidayu", alidayuErrorCodes, code, message, errorResponse.Result.RequestId)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取请求字符串
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) GetRequestString(params map[string]string) string {
+	//签名串
+	sign := c.Sign(params)
+
+	//参数值url编码
+	var options []string = make([]string, 0)
+	for k, v := range params {
+		item := fmt.Sprintf("%s=%s", k, url.QueryEscape(v))
+		options = append(options, item)
+	}
+
+	//把签名拼接到参数
+	options = append(options, fmt.Sprintf("%s=%s", "sign", url.QueryEscape(sign)))
+
+	//用&链接请求参数
+	return strings.Join(options, "&")
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 签名算法
+ * 参数按key升序拼接成key1value1key2value2...，按sign_method计算大写签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) Sign(params map[string]string) string {
+	var keys []string = make([]string, 0)
+	var values []string = make([]string, 0)
+
+	//请求参数排序（字母升序）
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	//拼接KeyValue字符串
+	for _, key := range keys {
+		if len(params[key]) > 0 {
+			values = append(values, key)         //Key
+			values = append(values, params[key]) //Value
+		}
+	}
+	paramString := strings.Join(values, "")
+
+	if params["sign_method"] == TopSignMethodHmac {
+		//HMAC-MD5签名（以api密匙为密钥）
+		mac := hmac.New(md5.New, []byte(c.AppSecret))
+		mac.Write([]byte(paramString))
+
+		return strings.ToUpper(hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	//Md5签名（在拼接的字符串头尾附加上api密匙，然后md5，md5串是大写）
+	paramString = fmt.Sprintf("%s%s%s", c.AppSecret, paramString, c.AppSecret)
+	sign := glib.Md5(paramString)
+
+	return strings.ToUpper(sign)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 读取网关和精简设置
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) resolve() (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.Geteway, c.Simplify
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取参数字典，在业务参数上附加TOP公共参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) toDict(method string, bizParams map[string]string, simplify bool) map[string]string {
+	var params map[string]string = make(map[string]string, 0)
+	for key, value := range bizParams {
+		params[key] = value
+	}
+
+	params["app_key"] = c.AppKey
+	params["method"] = method
+	params["format"] = c.Format
+	params["sign_method"] = c.SignMethod
+	params["v"] = c.Version
+	params["timestamp"] = c.options.now().In(chinaLocation).Format("2006-01-02 15:04:05") //北京时间，每次请求重新生成
+
+	if simplify {
+		params["simplify"] = "true"
+	}
+
+	return params
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否为失败响应：Json根对象的键或Xml根节点名为error_response
+ * 只判断顶层，业务数据中包含error_response文本时不会误判
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) isErrorResponse(resp *httpResponse) bool {
+	if resp.isXml(c.Format) {
+		decoder := xml.NewDecoder(strings.NewReader(resp.Body))
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return false
+			}
+
+			if element, isOk := token.(xml.StartElement); isOk {
+				return element.Name.Local == "error_response"
+			}
+		}
+	}
+
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(resp.Body), &root); err != nil {
+		return false
+	}
+
+	_, isOk := root["error_response"]
+
+	return isOk
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析非精简Json响应：alibaba.aliqin.fc.sms.num.send => alibaba_aliqin_fc_sms_num_send_response
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (c *topClient) unwrap(resp *httpResponse, method string, response interface{}) error {
+	var wrapper map[string]json.RawMessage
+	if err := resp.decode(c.Format, &wrapper); err != nil {
+		return err
+	}
+
+	key := strings.Replace(method, ".", "_", -1) + "_response"
+	body, ok := wrapper[key]
+	if !ok {
+		return fmt.Errorf("%w: 响应缺少%s", ErrProvider, key)
+	}
+
+	wrapped := &httpResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	}
+
+	return wrapped.decode(c.Format, response)
+}
@@ -0,0 +1,165 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// 淘宝开放平台签名文档示例
+var topSignParams = map[string]string{
+	"app_key":     "12345678",
+	"fields":      "num_iid,title,nick,price,num",
+	"format":      "json",
+	"method":      "taobao.item.seller.get",
+	"num_iid":     "11223344",
+	"session":     "test",
+	"sign_method": "md5",
+	"timestamp":   "2016-01-01 12:00:00",
+	"v":           "2.0",
+}
+
+func TestTopSignKnownAnswer(t *testing.T) {
+	cases := map[string]string{
+		TopSignMethodMd5:  "66987CB115214E59E6EC978214934FB8",
+		TopSignMethodHmac: "D56D7858309C31B6251083A874D48273",
+	}
+
+	for signMethod, want := range cases {
+		var form url.Values
+		transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(r.Body)
+			form, _ = url.ParseQuery(string(body))
+			return newTestResponse(`{"taobao_item_seller_get_response":{}}`), nil
+		})
+
+		client := NewTopClient("12345678", "helloworld",
+			WithTopSignMethod(signMethod),
+			WithTransport(transport),
+			WithClock(fixedClock("2016-01-01T04:00:00Z")))
+		client.SetSimplify(false)
+
+		params := map[string]string{
+			"fields":  topSignParams["fields"],
+			"num_iid": topSignParams["num_iid"],
+			"session": topSignParams["session"],
+		}
+		if err := client.Call(context.Background(), "taobao.item.seller.get", params, &struct{}{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := form.Get("timestamp"); got != "2016-01-01 12:00:00" {
+			t.Fatalf("%s: got timestamp %s", signMethod, got)
+		}
+		if got := form.Get("sign"); got != want {
+			t.Fatalf("%s: got sign %s, want %s", signMethod, got, want)
+		}
+
+		signParams := make(map[string]string, 0)
+		for key, value := range topSignParams {
+			signParams[key] = value
+		}
+		signParams["sign_method"] = signMethod
+		if got := client.Sign(signParams); got != want {
+			t.Fatalf("%s: Sign got %s, want %s", signMethod, got, want)
+		}
+	}
+}
+
+func TestTopRejectsUnknownSignMethod(t *testing.T) {
+	for _, signMethod := range []string{"sha1", "HMAC", "hmac-sha256"} {
+		transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			t.Fatal("request should not be sent")
+			return nil, nil
+		})
+
+		client := NewTopClient("key", "secret", WithTopSignMethod(signMethod), WithTransport(transport))
+		err := client.Call(context.Background(), "alibaba.aliqin.fc.sms.num.send", map[string]string{}, &struct{}{})
+		if !errors.Is(err, ErrInvalidArgument) {
+			t.Fatalf("%s: got %v, want ErrInvalidArgument", signMethod, err)
+		}
+	}
+}
+
+// 返回固定响应的TOP客户端
+func newTopTestClient(body string, opts ...Option) TopClient {
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return newTestResponse(body), nil
+	})
+
+	return NewTopClient("key", "secret", append(opts, WithTransport(transport))...)
+}
+
+type topTestResponse struct {
+	Result struct {
+		Model   string `json:"model" xml:"model"`
+		Success bool   `json:"success" xml:"success"`
+	} `json:"result" xml:"result"`
+	RequestId string `json:"request_id" xml:"request_id"`
+}
+
+func TestTopUnwrapResponse(t *testing.T) {
+	client := newTopTestClient(`{"alibaba_aliqin_fc_sms_num_send_response":{"result":{"model":"m1","success":true},"request_id":"r1"}}`)
+	client.SetSimplify(false)
+
+	response := new(topTestResponse)
+	if err := client.Call(context.Background(), "alibaba.aliqin.fc.sms.num.send", map[string]string{}, response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Result.Model != "m1" || !response.Result.Success || response.RequestId != "r1" {
+		t.Fatalf("got %#v", response)
+	}
+
+	//响应的包装键与方法名不一致
+	err := client.Call(context.Background(), "alibaba.aliqin.fc.sms.num.query", map[string]string{}, new(topTestResponse))
+	if !errors.Is(err, ErrProvider) {
+		t.Fatalf("got %v, want ErrProvider", err)
+	}
+}
+
+func TestTopErrorResponse(t *testing.T) {
+	cases := []struct {
+		body    string
+		code    string
+		message string
+		err     error
+	}{
+		{
+			`{"error_response":{"code":15,"msg":"Remote service error","sub_code":"isv.MOBILE_NUMBER_ILLEGAL","sub_msg":"号码格式错误","request_id":"r1"}}`,
+			"isv.MOBILE_NUMBER_ILLEGAL", "号码格式错误", ErrInvalidMobile,
+		},
+		{
+			`{"error_response":{"code":7,"msg":"App Call Limited","request_id":"r1"}}`,
+			"7", "App Call Limited", ErrRateLimited,
+		},
+	}
+
+	for _, simplify := range []bool{true, false} {
+		for _, c := range cases {
+			client := newTopTestClient(c.body)
+			client.SetSimplify(simplify)
+
+			err := client.Call(context.Background(), "alibaba.aliqin.fc.sms.num.send", map[string]string{}, new(topTestResponse))
+
+			var smsErr *SmsError
+			if !errors.As(err, &smsErr) || smsErr.Code != c.code || smsErr.Message != c.message || smsErr.RequestId != "r1" || !errors.Is(err, c.err) {
+				t.Errorf("simplify %v %s: got %#v", simplify, c.body, err)
+			}
+		}
+	}
+}
+
+func TestTopSuccessContainingErrorResponseText(t *testing.T) {
+	client := newTopTestClient(`{"result":{"model":"user typed error_response","success":true},"request_id":"r1"}`)
+
+	response := new(topTestResponse)
+	if err := client.Call(context.Background(), "alibaba.aliqin.fc.sms.num.send", map[string]string{}, response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Result.Model != "user typed error_response" || !response.Result.Success {
+		t.Fatalf("got %#v", response)
+	}
+}
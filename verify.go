@@ -0,0 +1,60 @@
+package gsms
+
+import (
+	"context"
+	"fmt"
+)
+
+/* ================================================================================
+ * 验证码校验
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	CodeVerified CodeVerifyStatus = 1 //校验通过
+	CodeExpired  CodeVerifyStatus = 2 //验证码已过期
+	CodeMismatch CodeVerifyStatus = 3 //验证码不正确
+)
+
+type (
+	CodeVerifyStatus int
+
+	/*
+	 * 由短信服务校验验证码（验证码由服务端生成并发送）
+	 * 过期和不匹配通过结果的Status返回，其它失败返回错误
+	 */
+	CodeVerifier interface {
+		CheckCode(ctx context.Context, mobile, code string) (*CodeVerifyResult, error)
+	}
+
+	CodeVerifyResult struct {
+		Status    CodeVerifyStatus `form:"status" json:"status"`         //校验状态
+		Code      string           `form:"code" json:"code"`             //网关返回码
+		Message   string           `form:"msg" json:"msg"`               //网关返回信息
+		RequestId string           `form:"request_id" json:"request_id"` //请求Id
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否校验通过
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *CodeVerifyResult) IsVerified() bool {
+	return r.Status == CodeVerified
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验状态描述
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (status CodeVerifyStatus) String() string {
+	switch status {
+	case CodeVerified:
+		return "verified"
+	case CodeExpired:
+		return "expired"
+	case CodeMismatch:
+		return "mismatch"
+	}
+
+	return fmt.Sprintf("unknown(%d)", int(status))
+}
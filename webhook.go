@@ -0,0 +1,464 @@
+package gsms
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ================================================================================
+ * 短信回执和上行短信推送接收
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	maxWebhookBodySize = 1 << 20 //推送内容最大1MB
+)
+
+type (
+	/*
+	 * 通用推送处理：读取请求、解析厂商格式并调用回调、返回厂商要求的应答
+	 */
+	pushHandler struct {
+		handle func(r *http.Request, body []byte) error
+		ack    func(w http.ResponseWriter, err error)
+	}
+
+	yegouReport struct {
+		Rrid        string `json:"rrid"`
+		Mobile      string `json:"mobile"`
+		Status      string `json:"status"`
+		ErrorCode   string `json:"errcode"`
+		Message     string `json:"message"`
+		SendTime    string `json:"sendTime"`
+		DeliverTime string `json:"deliverTime"`
+	}
+
+	huaweiReport struct {
+		SmsMsgId   string
+		To         string
+		Status     string
+		OrgCode    string
+		Extend     string
+		UpdateTime string
+	}
+
+	tencentReport struct {
+		UserReceiveTime string `json:"user_receive_time"`
+		NationCode      string `json:"nationcode"`
+		Mobile          string `json:"mobile"`
+		ReportStatus    string `json:"report_status"`
+		ErrMsg          string `json:"errmsg"`
+		Description     string `json:"description"`
+		Sid             string `json:"sid"`
+		Ext             string `json:"ext"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 阿里云短信回执HTTP批量推送
+ * 推送内容为SmsReport的Json数组，应答{"code":0,"msg":"成功"}，其它应答阿里云会重新推送
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunReportHandler(callback DeliveryReportHandler) http.Handler {
+	return &pushHandler{
+		handle: func(r *http.Request, body []byte) error {
+			reports, err := parseAliyunReports(body)
+			if err != nil {
+				return err
+			}
+
+			return dispatchReports(r.Context(), reports, callback)
+		},
+		ack: aliyunPushAck,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 阿里云上行短信HTTP批量推送
+ * 推送内容为SmsUp的Json数组，应答{"code":0,"msg":"成功"}，其它应答阿里云会重新推送
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAliyunInboundHandler(callback InboundMessageHandler) http.Handler {
+	return &pushHandler{
+		handle: func(r *http.Request, body []byte) error {
+			var smsUps []aliyunSmsUp
+			if err := json.Unmarshal(body, &smsUps); err != nil {
+				return fmt.Errorf("%w: 上行短信解析失败 %v", ErrInvalidArgument, err)
+			}
+
+			for i := range smsUps {
+				if err := callback(r.Context(), smsUps[i].toInboundMessage()); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		ack: aliyunPushAck,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 野狗短信回执推送
+ * 推送内容为表单或Json，包含rrid、mobile、status、errcode、message、sendTime、deliverTime、signature字段
+ * signature按发送请求相同的规则使用appSecret校验，缺失或不匹配时拒绝
+ * 应答{"status":"ok"}
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewYeGouReportHandler(appSecret string, callback DeliveryReportHandler) http.Handler {
+	return &pushHandler{
+		handle: func(r *http.Request, body []byte) error {
+			reports, err := parseYeGouReport(r, body, appSecret)
+			if err != nil {
+				return err
+			}
+
+			return dispatchReports(r.Context(), reports, callback)
+		},
+		ack: yegouPushAck,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 华为云短信状态报告推送（发送时通过WithHuaweiStatusCallback设置的地址）
+ * 推送内容为表单，包含smsMsgId、from、to、status、orgCode、extend、updateTime字段
+ * 应答200表示接收成功
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewHuaweiReportHandler(callback DeliveryReportHandler) http.Handler {
+	return &pushHandler{
+		handle: func(r *http.Request, body []byte) error {
+			reports, err := parseHuaweiReport(body)
+			if err != nil {
+				return err
+			}
+
+			return dispatchReports(r.Context(), reports, callback)
+		},
+		ack: statusPushAck,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 腾讯云短信状态回执推送（在控制台配置回调地址）
+ * 推送内容为回执的Json数组，应答{"result":0,"errmsg":"OK"}
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewTencentReportHandler(callback DeliveryReportHandler) http.Handler {
+	return &pushHandler{
+		handle: func(r *http.Request, body []byte) error {
+			reports, err := parseTencentReports(body)
+			if err != nil {
+				return err
+			}
+
+			return dispatchReports(r.Context(), reports, callback)
+		},
+		ack: tencentPushAck,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 处理推送请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (h *pushHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		h.ack(w, err)
+		return
+	}
+
+	h.ack(w, h.handle(r, body))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 依次调用回执回调，遇到错误即返回
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func dispatchReports(ctx context.Context, reports []*DeliveryReport, callback DeliveryReportHandler) error {
+	for _, report := range reports {
+		if err := callback(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析阿里云推送的回执数组
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseAliyunReports(body []byte) ([]*DeliveryReport, error) {
+	var smsReports []aliyunSmsReport
+	if err := json.Unmarshal(body, &smsReports); err != nil {
+		return nil, fmt.Errorf("%w: 回执解析失败 %v", ErrInvalidArgument, err)
+	}
+
+	reports := make([]*DeliveryReport, 0, len(smsReports))
+	for i := range smsReports {
+		reports = append(reports, smsReports[i].toDeliveryReport())
+	}
+
+	return reports, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 阿里云推送应答
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func aliyunPushAck(w http.ResponseWriter, err error) {
+	code, msg := 0, "成功"
+	status := http.StatusOK
+	if err != nil {
+		code, msg = 1, err.Error()
+
+		//推送内容无法解析时返回400，回调失败时返回500，阿里云都会重新推送
+		status = http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidArgument) {
+			status = http.StatusBadRequest
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": code,
+		"msg":  msg,
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析野狗推送的回执并校验签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseYeGouReport(r *http.Request, body []byte, appSecret string) ([]*DeliveryReport, error) {
+	params := make(map[string]string, 0)
+
+	if strings.Contains(r.Header.Get("Content-Type"), "json") {
+		//保留数字的原始文本，避免大整数（毫秒时间戳等）按浮点数格式化导致验签失败
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(body, &values); err != nil {
+			return nil, fmt.Errorf("%w: 回执解析失败 %v", ErrInvalidArgument, err)
+		}
+
+		for key, value := range values {
+			param, err := jsonValueString(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 回执解析失败 %v", ErrInvalidArgument, err)
+			}
+			params[key] = param
+		}
+	} else {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: 回执解析失败 %v", ErrInvalidArgument, err)
+		}
+
+		for key := range values {
+			params[key] = values.Get(key)
+		}
+	}
+
+	//校验签名
+	signature := params["signature"]
+	delete(params, "signature")
+	if subtle.ConstantTimeCompare([]byte(strings.ToLower(signature)), []byte(yegouSign(params, appSecret))) != 1 {
+		return nil, fmt.Errorf("%w: 回执签名不正确", ErrSignatureInvalid)
+	}
+
+	yegou := yegouReport{
+		Rrid:        params["rrid"],
+		Mobile:      params["mobile"],
+		Status:      params["status"],
+		ErrorCode:   params["errcode"],
+		Message:     params["message"],
+		SendTime:    params["sendTime"],
+		DeliverTime: params["deliverTime"],
+	}
+
+	return []*DeliveryReport{yegou.toDeliveryReport()}, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为统一回执
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *yegouReport) toDeliveryReport() *DeliveryReport {
+	report := new(DeliveryReport)
+	report.Provider = "yegou"
+	report.MessageId = r.Rrid
+	report.Mobile = r.Mobile
+	report.ErrorCode = r.ErrorCode
+	report.ErrorMessage = r.Message
+	report.SendTime = parseMillisecondTime(r.SendTime)
+	report.ReportTime = parseMillisecondTime(r.DeliverTime)
+
+	report.Status = yegouDeliveryStatus(r.Status)
+
+	return report
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 野狗推送应答
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func yegouPushAck(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析华为云推送的状态报告
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseHuaweiReport(body []byte) ([]*DeliveryReport, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 状态报告解析失败 %v", ErrInvalidArgument, err)
+	}
+
+	if len(values.Get("smsMsgId")) == 0 || len(values.Get("status")) == 0 {
+		return nil, fmt.Errorf("%w: 状态报告缺少smsMsgId或status", ErrInvalidArgument)
+	}
+
+	huawei := huaweiReport{
+		SmsMsgId:   values.Get("smsMsgId"),
+		To:         values.Get("to"),
+		Status:     values.Get("status"),
+		OrgCode:    values.Get("orgCode"),
+		Extend:     values.Get("extend"),
+		UpdateTime: values.Get("updateTime"),
+	}
+
+	return []*DeliveryReport{huawei.toDeliveryReport()}, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为统一回执
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *huaweiReport) toDeliveryReport() *DeliveryReport {
+	report := new(DeliveryReport)
+	report.Provider = "huawei"
+	report.MessageId = r.SmsMsgId
+	report.Mobile = r.To
+	report.ErrorCode = r.OrgCode
+	report.ErrorMessage = r.Status
+	report.OutId = r.Extend
+
+	//updateTime为UTC时间，如2018-10-31T08:43:41Z
+	if reportTime, err := time.Parse(time.RFC3339, r.UpdateTime); err == nil {
+		report.ReportTime = reportTime
+	}
+
+	//DELIVRD为用户已接收，ACCEPTD为平台已受理的中间状态，其它为发送失败
+	switch strings.ToUpper(r.Status) {
+	case "DELIVRD":
+		report.Status = DeliveryStatusDelivered
+	case "ACCEPTD":
+		report.Status = DeliveryStatusUnknown
+	default:
+		report.Status = DeliveryStatusFailed
+	}
+
+	return report
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析腾讯云推送的回执数组
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseTencentReports(body []byte) ([]*DeliveryReport, error) {
+	var tencentReports []tencentReport
+	if err := json.Unmarshal(body, &tencentReports); err != nil {
+		return nil, fmt.Errorf("%w: 回执解析失败 %v", ErrInvalidArgument, err)
+	}
+
+	reports := make([]*DeliveryReport, 0, len(tencentReports))
+	for i := range tencentReports {
+		reports = append(reports, tencentReports[i].toDeliveryReport())
+	}
+
+	return reports, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 转换为统一回执
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (r *tencentReport) toDeliveryReport() *DeliveryReport {
+	report := new(DeliveryReport)
+	report.Provider = "tencent"
+	report.MessageId = r.Sid
+	report.Mobile = r.Mobile
+	report.ErrorCode = r.ErrMsg
+	report.ErrorMessage = r.Description
+	report.OutId = r.Ext
+	report.ReportTime = parseChinaTime(r.UserReceiveTime)
+
+	if len(r.NationCode) > 0 && r.NationCode != "86" {
+		report.Mobile = "+" + r.NationCode + r.Mobile
+	}
+
+	switch strings.ToUpper(r.ReportStatus) {
+	case "SUCCESS":
+		report.Status = DeliveryStatusDelivered
+	case "FAIL":
+		report.Status = DeliveryStatusFailed
+	default:
+		report.Status = DeliveryStatusUnknown
+	}
+
+	return report
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 腾讯云推送应答
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func tencentPushAck(w http.ResponseWriter, err error) {
+	result, errmsg := 0, "OK"
+	if err != nil {
+		result, errmsg = 1, err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"result": result,
+		"errmsg": errmsg,
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按HTTP状态码应答，成功返回200，失败返回400
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func statusPushAck(w http.ResponseWriter, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析毫秒时间戳，格式不正确时返回零值
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseMillisecondTime(value string) time.Time {
+	milliseconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || milliseconds <= 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, milliseconds*int64(time.Millisecond))
+}
@@ -0,0 +1,217 @@
+package gsms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestYeGouReportHandlerNumericJson(t *testing.T) {
+	params := map[string]string{
+		"rrid":        "r1",
+		"mobile":      "13800000000",
+		"status":      "DELIVERED",
+		"errcode":     "0",
+		"sendTime":    "1700000000123",
+		"deliverTime": "1700000001456",
+	}
+	body := fmt.Sprintf(`{"rrid":"r1","mobile":"13800000000","status":"DELIVERED","errcode":0,"sendTime":1700000000123,"deliverTime":1700000001456,"signature":"%s"}`,
+		yegouSign(params, "secret"))
+
+	var report *DeliveryReport
+	handler := NewYeGouReportHandler("secret", func(ctx context.Context, r *DeliveryReport) error {
+		report = r
+		return nil
+	})
+
+	request := httptest.NewRequest(http.MethodPost, "/sms/yegou/report", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"ok"`) {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if report == nil || report.MessageId != "r1" || report.Status != DeliveryStatusDelivered || report.ErrorCode != "0" {
+		t.Fatalf("got %#v", report)
+	}
+
+	if !report.SendTime.Equal(time.Unix(0, 1700000000123*int64(time.Millisecond))) {
+		t.Fatalf("got send time %v", report.SendTime)
+	}
+}
+
+func TestYeGouReportHandlerRejectsBadSignature(t *testing.T) {
+	handler := NewYeGouReportHandler("secret", func(ctx context.Context, r *DeliveryReport) error {
+		t.Fatal("callback should not be called")
+		return nil
+	})
+
+	body := `{"rrid":"r1","mobile":"13800000000","status":"DELIVERED","sendTime":1700000000123,"signature":"bad"}`
+	request := httptest.NewRequest(http.MethodPost, "/sms/yegou/report", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestAliyunReportHandler(t *testing.T) {
+	var reports []*DeliveryReport
+	handler := NewAliyunReportHandler(func(ctx context.Context, r *DeliveryReport) error {
+		reports = append(reports, r)
+		return nil
+	})
+
+	body := `[{"phone_number":"13800000000","send_time":"2017-01-01 00:00:00","report_time":"2017-01-01 00:00:05","success":true,` +
+		`"err_code":"DELIVERED","err_msg":"用户接收成功","sms_size":"2","biz_id":"biz-1","out_id":"out-1"},` +
+		`{"phone_number":"13900000000","send_time":"2017-01-01 00:00:00","report_time":"2017-01-01 00:00:08","success":false,` +
+		`"err_code":"MK:0001","err_msg":"空号","sms_size":1,"biz_id":"biz-2"}]`
+	request := httptest.NewRequest(http.MethodPost, "/sms/aliyun/report", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK || strings.TrimSpace(recorder.Body.String()) != `{"code":0,"msg":"成功"}` {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports", len(reports))
+	}
+
+	delivered := reports[0]
+	if delivered.Provider != "aliyun" || delivered.MessageId != "biz-1" || delivered.OutId != "out-1" || delivered.SmsSize != 2 ||
+		delivered.Status != DeliveryStatusDelivered || !delivered.ReportTime.Equal(time.Date(2017, 1, 1, 0, 0, 5, 0, chinaLocation)) {
+		t.Fatalf("got %#v", delivered)
+	}
+
+	failed := reports[1]
+	if failed.MessageId != "biz-2" || failed.Status != DeliveryStatusFailed || failed.ErrorCode != "MK:0001" || failed.SmsSize != 1 {
+		t.Fatalf("got %#v", failed)
+	}
+}
+
+func TestAliyunReportHandlerErrors(t *testing.T) {
+	handler := NewAliyunReportHandler(func(ctx context.Context, r *DeliveryReport) error {
+		return fmt.Errorf("db unavailable")
+	})
+
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		code   int
+		ack    string
+	}{
+		{"malformed", http.MethodPost, `{"phone_number":`, http.StatusBadRequest, `"code":1`},
+		{"object", http.MethodPost, `{"phone_number":"13800000000"}`, http.StatusBadRequest, `"code":1`},
+		{"callback error", http.MethodPost, `[{"biz_id":"biz-1","success":true}]`, http.StatusInternalServerError, `"code":1`},
+		{"method", http.MethodGet, ``, http.StatusMethodNotAllowed, ``},
+	}
+
+	for _, c := range cases {
+		request := httptest.NewRequest(c.method, "/sms/aliyun/report", strings.NewReader(c.body))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		//阿里云按应答内容判断是否重新推送，非0的code都会重推
+		if recorder.Code != c.code || !strings.Contains(recorder.Body.String(), c.ack) {
+			t.Errorf("%s: got %d %s", c.name, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestHuaweiReportHandler(t *testing.T) {
+	var report *DeliveryReport
+	handler := NewHuaweiReportHandler(func(ctx context.Context, r *DeliveryReport) error {
+		report = r
+		return nil
+	})
+
+	body := "smsMsgId=2ea20735-f856-4376-afbf-570bd70a46ee_11840135&from=8820032023657&to=%2B8613800000000" +
+		"&status=DELIVRD&orgCode=DELIVRD&extend=out-1&updateTime=2018-10-31T08%3A43%3A41Z"
+	request := httptest.NewRequest(http.MethodPost, "/sms/huawei/report", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if report == nil || report.Provider != "huawei" || report.MessageId != "2ea20735-f856-4376-afbf-570bd70a46ee_11840135" ||
+		report.Mobile != "+8613800000000" || report.Status != DeliveryStatusDelivered || report.OutId != "out-1" ||
+		!report.ReportTime.Equal(time.Date(2018, 10, 31, 8, 43, 41, 0, time.UTC)) {
+		t.Fatalf("got %#v", report)
+	}
+
+	statuses := map[string]DeliveryStatus{
+		"ACCEPTD": DeliveryStatusUnknown,
+		"UNDELIV": DeliveryStatusFailed,
+		"EXPIRED": DeliveryStatusFailed,
+	}
+	for status, want := range statuses {
+		request := httptest.NewRequest(http.MethodPost, "/sms/huawei/report", strings.NewReader("smsMsgId=m1&status="+status))
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		if report.Status != want || report.ErrorMessage != status {
+			t.Errorf("%s: got %#v", status, report)
+		}
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/sms/huawei/report", strings.NewReader("from=8820032023657"))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestTencentReportHandler(t *testing.T) {
+	var reports []*DeliveryReport
+	handler := NewTencentReportHandler(func(ctx context.Context, r *DeliveryReport) error {
+		reports = append(reports, r)
+		return nil
+	})
+
+	body := `[{"user_receive_time":"2015-10-17 08:03:04","nationcode":"86","mobile":"13800000000","report_status":"SUCCESS",` +
+		`"errmsg":"DELIVRD","description":"用户短信送达成功","sid":"2019:-2158925012543961337","ext":"out-1"},` +
+		`{"user_receive_time":"2015-10-17 08:03:05","nationcode":"1","mobile":"4155552671","report_status":"FAIL",` +
+		`"errmsg":"MK:0001","description":"空号","sid":"2019:-2158925012543961338","ext":""}]`
+	request := httptest.NewRequest(http.MethodPost, "/sms/tencent/report", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK || strings.TrimSpace(recorder.Body.String()) != `{"errmsg":"OK","result":0}` {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports", len(reports))
+	}
+
+	delivered := reports[0]
+	if delivered.Provider != "tencent" || delivered.MessageId != "2019:-2158925012543961337" || delivered.Mobile != "13800000000" ||
+		delivered.Status != DeliveryStatusDelivered || delivered.ErrorCode != "DELIVRD" || delivered.OutId != "out-1" ||
+		!delivered.ReportTime.Equal(time.Date(2015, 10, 17, 8, 3, 4, 0, chinaLocation)) {
+		t.Fatalf("got %#v", delivered)
+	}
+
+	failed := reports[1]
+	if failed.Mobile != "+14155552671" || failed.Status != DeliveryStatusFailed || failed.ErrorCode != "MK:0001" || failed.ErrorMessage != "空号" {
+		t.Fatalf("got %#v", failed)
+	}
+
+	request = httptest.NewRequest(http.MethodPost, "/sms/tencent/report", strings.NewReader(`{"sid":`))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if !strings.Contains(recorder.Body.String(), `"result":1`) {
+		t.Fatalf("got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -1,11 +1,15 @@
 package gsms
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 import (
@@ -18,48 +22,90 @@ import (
  * email   : 2091938785@qq.com
  * author  : 美丽的地球啊 - mliu
  * ================================================================================ */
+const (
+	YeGouSendTypeCode   = "code"   //验证码，只能发送给一个手机号
+	YeGouSendTypeNotify = "notify" //通知，可发送给多个手机号
+)
+
 type (
 	getewayUrl struct {
-		Code   string
-		Notify string
-		Check  string
+		Code    string
+		Notify  string
+		Check   string
+		Status  string
+		Balance string
 	}
 
 	yegouSms struct {
-		Geteway    string     `form:"geteway" json:"geteway"`
-		Url        getewayUrl `form:"geteway_url" json:"geteway_url"`
-		AppKey     string     `form:"app_key" json:"app_key"`
-		AppSecret  string     `form:"app_secret" json:"app_secret"`
-		Mobiles    []string   `form:"mobiles" json:"mobiles"`
-		TemplateId string     `form:"template_id" json:"template_id"`
-		Params     []string   `form:"params" json:"params"`
-		Type       string     `form:"type" json:"type"`
-		Timestamp  string     `form:"timestamp" json:"timestamp"`
+		Geteway        string            `form:"geteway" json:"geteway"`
+		Url            getewayUrl        `form:"geteway_url" json:"geteway_url"`
+		AppKey         string            `form:"app_key" json:"app_key"`
+		AppSecret      string            `form:"app_secret" json:"app_secret"`
+		TemplateId     string            `form:"template_id" json:"template_id"`         //默认模版Id
+		TemplateParam  SmsTemplateParams `form:"template_param" json:"template_param"`   //默认模版参数
+		TemplateString string            `form:"template_string" json:"template_string"` //默认模版参数字符串（单个参数）
+		Type           string            `form:"type" json:"type"`
+		options        options
+		mu             sync.RWMutex
 	}
 
-	yegouErrorResult struct {
-		Errcode int    `form:"errcode" json:"errcode"`
-		Message string `form:"message" json:"message"`
+	/*
+	 * 野狗响应
+	 * {"status" : "ok","data":{"rrid":"bdd977d825084bd0ad7a00597dbd0f69"}}
+	 * {"errcode": 79998,"message": "request error ,error is null"}
+	 */
+	yegouResponse struct {
+		Status  string          `form:"status" json:"status"`
+		Data    json.RawMessage `form:"data" json:"data"`
+		Errcode json.Number     `form:"errcode" json:"errcode"`
+		Message string          `form:"message" json:"message"`
+	}
+
+	yegouSendResult struct {
+		Rrid string `form:"rrid" json:"rrid"`
+	}
+
+	/*
+	 * 野狗短信提供者，支持验证码校验、发送状态和余额查询
+	 */
+	YeGouSmsProvider interface {
+		SmsProvider
+		CodeVerifier
+		QueryStatus(ctx context.Context, rrid string) ([]*DeliveryReport, error)
+		Balance(ctx context.Context) (*YeGouBalance, error)
 	}
 )
 
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置野狗发送类型（YeGouSendTypeCode或YeGouSendTypeNotify），默认验证码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func WithYeGouSendType(sendType string) Option {
+	return func(o *options) {
+		o.yegouSendType = sendType
+	}
+}
+
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 创建野狗短信提供者
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func NewYeGouSms(appKey, appSecret string) SmsProvider {
+func NewYeGouSms(appKey, appSecret string, opts ...Option) YeGouSmsProvider {
 	sms := new(yegouSms)
 	sms.AppKey = appKey
 	sms.AppSecret = appSecret
 	sms.Geteway = "https://sms.wilddog.com/api/v1/"
 	sms.Url = getewayUrl{
-		Code:   "/code/send",
-		Notify: "/notify/send",
-		Check:  "/code/check",
+		Code:    "/code/send",
+		Notify:  "/notify/send",
+		Check:   "/code/check",
+		Status:  "/status",
+		Balance: "/getBalance",
 	}
-	sms.Type = "code"
+	sms.Type = YeGouSendTypeCode
+	sms.options = newOptions(opts)
 
-	timespame := glib.UnixTimestamp() * int64(1000)
-	sms.Timestamp = fmt.Sprintf("%d", timespame)
+	if len(sms.options.yegouSendType) > 0 {
+		sms.Type = strings.ToLower(sms.options.yegouSendType)
+	}
 
 	return sms
 }
@@ -68,55 +114,120 @@ func NewYeGouSms(appKey, appSecret string) SmsProvider {
  * 发送手机信息
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *yegouSms) Send(mobile string) (*SmsResult, error) {
+	return s.SendContext(context.Background(), mobile)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送手机信息，ctx取消或超时会中断请求
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) SendContext(ctx context.Context, mobile string) (*SmsResult, error) {
+	return s.SendMessage(ctx, NewMessage(splitMobiles(mobile)...))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 发送短信消息，可并发调用
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) SendMessage(ctx context.Context, message *Message) (*SmsResult, error) {
 	result := new(SmsResult)
 
-	if len(mobile) == 0 {
-		return result, errors.New("手机号不能为空")
+	if message == nil || len(message.Mobiles) == 0 {
+		return result, ErrInvalidMobile
 	}
 
-	if len(s.TemplateId) == 0 {
-		return nil, errors.New("参数不正确")
+	//验证码只能发送给一个手机号
+	if s.Type != YeGouSendTypeNotify && len(message.Mobiles) > 1 {
+		return result, fmt.Errorf("%w: 验证码只能发送给一个手机号，多个手机号请使用通知类型", ErrInvalidMobile)
 	}
 
-	//接收手机号码
-	s.Mobiles = []string{mobile}
+	//合并默认值
+	message, params := s.resolve(message)
+	result.OutId = message.OutId
+
+	if len(message.TemplateCode) == 0 {
+		return result, ErrTemplateMissing
+	}
 
-	//签名请求参数
-	requestString := s.GetRequestString()
+	if err := message.validateParams(); err != nil {
+		return result, err
+	}
 
-	url := s.Url.Code
-	if strings.ToLower(s.Type) == "notify" {
-		url = s.Url.Notify
+	path := s.Url.Code
+	if s.Type == YeGouSendTypeNotify {
+		path = s.Url.Notify
 	}
-	url = s.Geteway + s.AppKey + url
 
-	//发起Http请求
-	if response, err := glib.HttpPost(url, requestString); err != nil {
-		result.Message = err.Error()
+	//发送请求
+	sendResult := new(yegouSendResult)
+	if err := s.call(ctx, http.MethodPost, path, s.toDict(message, params), sendResult); err != nil {
+		result.setError(err)
 		return result, err
+	}
+
+	result.RequestId = sendResult.Rrid
+	result.IsSuccess = true
+
+	return result, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 调用野狗接口，参数附加时间戳并签名，errcode转换为SmsError，data解析到response
+ * GET请求的参数放在查询字符串，POST请求的参数放在表单
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) call(ctx context.Context, method, path string, params map[string]string, response interface{}) error {
+	if _, ok := params["timestamp"]; !ok {
+		params["timestamp"] = fmt.Sprintf("%d", s.options.now().UnixNano()/int64(time.Millisecond))
+	}
+
+	url := s.geteway() + s.AppKey + path
+	requestString := s.GetRequestString(params)
+
+	var resp *httpResponse
+	var err error
+	if method == http.MethodGet {
+		resp, err = s.options.httpDo(ctx, method, url+"?"+requestString, nil, "")
 	} else {
-		result.Message = response
-
-		//错误处理
-		//{"status" : "ok","data":{"rrid":"bdd977d825084bd0ad7a00597dbd0f69"}}
-		//{"errcode": 79998,"message": "request error ,error is null"}
-		var errorResult *yegouErrorResult
-		glib.FromJson(response, &errorResult)
-		if len(errorResult.Message) > 0 {
-			result.IsSuccess = false
-			return result, errors.New(errorResult.Message)
-		} else {
-			result.IsSuccess = true
+		resp, err = s.options.httpPost(ctx, url, requestString)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	yegou := new(yegouResponse)
+	if err := resp.decode("json", yegou); err != nil {
+		return err
+	}
+
+	if len(yegou.Errcode) > 0 || (len(yegou.Message) > 0 && yegou.Status != "ok") {
+		return newSmsError("yegou", yegouErrorCodes, yegou.Errcode.String(), yegou.Message, "")
+	}
+
+	if response != nil && len(yegou.Data) > 0 {
+		if err := json.Unmarshal(yegou.Data, response); err != nil {
+			return fmt.Errorf("%w: 响应解析失败 %v", ErrProvider, err)
 		}
 	}
 
-	return result, nil
+	return nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 设置模版Id
+ * 读取网关
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) geteway() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Geteway
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置发送网关
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *yegouSms) SetGeteway(geteway string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.Geteway = geteway
 }
 
@@ -124,21 +235,32 @@ func (s *yegouSms) SetGeteway(geteway string) {
  * 设置模版Id
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *yegouSms) SetTemplateCode(templateCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.TemplateId = templateCode
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 设置模版参数
+ * 设置模版参数（按顺序序列化为数组）
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *yegouSms) SetTemplateParam(templateParam SmsTemplateParam) {
-	s.Params = []string{templateParam.Code}
+func (s *yegouSms) SetTemplateParam(templateParam SmsTemplateParamer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateParam = toTemplateParams(templateParam)
+	s.TemplateString = ""
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 设置模版参数字符串
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *yegouSms) SetTemplateString(templateString string) {
-	s.Params = []string{templateString}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TemplateString = templateString
+	s.TemplateParam = nil
 }
 
 func (s *yegouSms) SetSignName(signName string) {
@@ -148,9 +270,7 @@ func (s *yegouSms) SetSignName(signName string) {
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 获取请求字符串
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *yegouSms) GetRequestString() string {
-	params := s.toDict()
-
+func (s *yegouSms) GetRequestString(params map[string]string) string {
 	//Md5签名串
 	sign := s.Sign(params)
 
@@ -174,6 +294,13 @@ func (s *yegouSms) GetRequestString() string {
  * fmt.Sprintf("%s=%s", key, url.QueryEscape(value))
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *yegouSms) Sign(params map[string]string) string {
+	return yegouSign(params, s.AppSecret)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 野狗签名：参数按key升序拼接成key=value&...，末尾附加&appSecret后Sha256
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func yegouSign(params map[string]string, appSecret string) string {
 	var keys []string = make([]string, 0)
 	var values []string = make([]string, 0)
 
@@ -193,31 +320,47 @@ func (s *yegouSms) Sign(params map[string]string) string {
 	paramString := strings.Join(values, "&")
 
 	//Sha256签名
-	signString := fmt.Sprintf("%s&%s", paramString, s.AppSecret)
+	signString := fmt.Sprintf("%s&%s", paramString, appSecret)
 
 	return glib.Sha256(signString)
 }
 
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 合并消息默认值，返回消息和模版参数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) resolve(message *Message) (*Message, []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	message = message.merge(Message{
+		TemplateCode:   s.TemplateId,
+		TemplateParam:  s.TemplateParam,
+		TemplateString: s.TemplateString,
+	})
+
+	return message, message.positionalParams()
+}
+
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 获取参数字典
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *yegouSms) toDict() map[string]string {
+func (s *yegouSms) toDict(message *Message, templateParams []string) map[string]string {
 	var params map[string]string = make(map[string]string, 0)
-	params["templateId"] = s.TemplateId
+	params["templateId"] = message.TemplateCode
 
-	if strings.ToLower(s.Type) == "notify" {
-		params["mobiles"] = glib.StringSliceToString(s.Mobiles)
+	if s.Type == YeGouSendTypeNotify {
+		mobiles, _ := json.Marshal(message.Mobiles)
+		params["mobiles"] = string(mobiles) //Json数组
 	} else {
-		params["mobile"] = s.Mobiles[0]
+		params["mobile"] = message.Mobiles[0]
 	}
 
-	if len(s.Params) > 0 {
-		paramsJson, _ := glib.ToJson(s.Params)
-		//params["params"] = glib.StringSliceToString(s.Params)
+	if len(templateParams) > 0 {
+		paramsJson, _ := glib.ToJson(templateParams)
 		params["params"] = paramsJson
 	}
 
-	params["timestamp"] = s.Timestamp
+	params["timestamp"] = fmt.Sprintf("%d", s.options.now().UnixNano()/int64(time.Millisecond)) //毫秒时间戳，每次请求重新生成
 
 	return params
 }
@@ -0,0 +1,60 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+/* ================================================================================
+ * 野狗验证码校验
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+// 验证码校验结果对应的野狗错误码
+var yegouCodeStatuses = map[string]CodeVerifyStatus{
+	"70053": CodeMismatch, //验证码错误
+	"70054": CodeExpired,  //验证码已过期
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验野狗发送的验证码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) CheckCode(ctx context.Context, mobile, code string) (*CodeVerifyResult, error) {
+	result := new(CodeVerifyResult)
+
+	if len(mobile) == 0 {
+		return result, ErrInvalidMobile
+	}
+
+	if len(code) == 0 {
+		return result, ErrInvalidArgument
+	}
+
+	params := make(map[string]string, 0)
+	params["mobile"] = mobile
+	params["code"] = code
+
+	err := s.call(ctx, http.MethodPost, s.Url.Check, params, nil)
+	if err == nil {
+		result.Status = CodeVerified
+		return result, nil
+	}
+
+	//验证码错误和过期的错误码作为校验结果返回，其它错误码原样返回SmsError
+	var smsErr *SmsError
+	if !errors.As(err, &smsErr) {
+		return result, err
+	}
+
+	result.Code = smsErr.Code
+	result.Message = smsErr.Message
+
+	if status, isOk := yegouCodeStatuses[smsErr.Code]; isOk {
+		result.Status = status
+		return result, nil
+	}
+
+	return result, err
+}
@@ -0,0 +1,105 @@
+package gsms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+/* ================================================================================
+ * 野狗发送状态和余额查询
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	YeGouBalance struct {
+		Sms   int64 `form:"sms" json:"sms"`     //短信剩余条数
+		Voice int64 `form:"voice" json:"voice"` //语音剩余条数
+	}
+
+	yegouStatusItem struct {
+		Rrid           string      `json:"rrid"`
+		Mobile         string      `json:"mobile"`
+		DeliveryStatus string      `json:"deliveryStatus"`
+		ErrorCode      string      `json:"errorCode"`
+		ErrorMessage   string      `json:"errorMsg"`
+		SendTime       json.Number `json:"sendTime"`    //毫秒时间戳
+		ReceiveTime    json.Number `json:"receiveTime"` //毫秒时间戳
+	}
+
+	yegouBalanceResponse struct {
+		Balance      json.Number `json:"balance"`
+		VoiceBalance json.Number `json:"voiceBalance"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 根据发送返回的rrid查询每个手机号的接收状态
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) QueryStatus(ctx context.Context, rrid string) ([]*DeliveryReport, error) {
+	if len(rrid) == 0 {
+		return nil, ErrInvalidArgument
+	}
+
+	params := make(map[string]string, 0)
+	params["rrid"] = rrid
+
+	var items []*yegouStatusItem
+	if err := s.call(ctx, http.MethodGet, s.Url.Status, params, &items); err != nil {
+		return nil, err
+	}
+
+	reports := make([]*DeliveryReport, 0, len(items))
+	for _, item := range items {
+		report := &yegouReport{
+			Rrid:        item.Rrid,
+			Mobile:      item.Mobile,
+			Status:      item.DeliveryStatus,
+			ErrorCode:   item.ErrorCode,
+			Message:     item.ErrorMessage,
+			SendTime:    item.SendTime.String(),
+			DeliverTime: item.ReceiveTime.String(),
+		}
+
+		if len(report.Rrid) == 0 {
+			report.Rrid = rrid
+		}
+
+		reports = append(reports, report.toDeliveryReport())
+	}
+
+	return reports, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 查询账户余额
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *yegouSms) Balance(ctx context.Context) (*YeGouBalance, error) {
+	balance := new(YeGouBalance)
+
+	var response yegouBalanceResponse
+	if err := s.call(ctx, http.MethodGet, s.Url.Balance, make(map[string]string, 0), &response); err != nil {
+		return balance, err
+	}
+
+	balance.Sms, _ = response.Balance.Int64()
+	balance.Voice, _ = response.VoiceBalance.Int64()
+
+	return balance, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 野狗接收状态转换为统一状态
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func yegouDeliveryStatus(status string) DeliveryStatus {
+	switch strings.ToLower(status) {
+	case "ok", "success", "delivrd", "delivered":
+		return DeliveryStatusDelivered
+	case "", "sending", "pending", "unknown":
+		return DeliveryStatusUnknown
+	}
+
+	return DeliveryStatusFailed
+}
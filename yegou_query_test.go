@@ -0,0 +1,83 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestYeGouQueryStatus(t *testing.T) {
+	sms, server := newYeGouRecordingServer(t, `{"status":"ok","data":[`+
+		`{"rrid":"rrid-1","mobile":"13800000000","deliveryStatus":"DELIVRD","errorCode":"0","sendTime":1700000000123,"receiveTime":1700000001456},`+
+		`{"mobile":"13900000000","deliveryStatus":"UNDELIV","errorCode":"MK:0001","errorMsg":"空号","sendTime":"1700000000123"}]}`)
+
+	reports, err := sms.QueryStatus(context.Background(), "rrid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := server.last()
+	if record.method != http.MethodGet || record.path != "/app/status" || record.params.Get("rrid") != "rrid-1" || !record.isSigned() {
+		t.Fatalf("got %s %s %v", record.method, record.path, record.params)
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("got %d reports", len(reports))
+	}
+
+	delivered := reports[0]
+	if delivered.Provider != "yegou" || delivered.MessageId != "rrid-1" || delivered.Mobile != "13800000000" || delivered.Status != DeliveryStatusDelivered ||
+		!delivered.ReportTime.Equal(time.Unix(0, 1700000001456*int64(time.Millisecond))) {
+		t.Fatalf("got %#v", delivered)
+	}
+
+	//缺少rrid时使用查询的rrid，数字和字符串时间戳都能解析
+	failed := reports[1]
+	if failed.MessageId != "rrid-1" || failed.Status != DeliveryStatusFailed || failed.ErrorCode != "MK:0001" || failed.ErrorMessage != "空号" ||
+		!failed.SendTime.Equal(time.Unix(0, 1700000000123*int64(time.Millisecond))) {
+		t.Fatalf("got %#v", failed)
+	}
+
+	if _, err := sms.QueryStatus(context.Background(), ""); err != ErrInvalidArgument {
+		t.Fatalf("got %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestYeGouBalance(t *testing.T) {
+	sms, server := newYeGouRecordingServer(t, `{"status":"ok","data":{"balance":120,"voiceBalance":"30"}}`)
+
+	balance, err := sms.Balance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := server.last()
+	if record.method != http.MethodGet || record.path != "/app/getBalance" || len(record.params.Get("timestamp")) == 0 || !record.isSigned() {
+		t.Fatalf("got %s %s %v", record.method, record.path, record.params)
+	}
+
+	if balance.Sms != 120 || balance.Voice != 30 {
+		t.Fatalf("got %#v", balance)
+	}
+}
+
+func TestYeGouQueryErrors(t *testing.T) {
+	sms, _ := newYeGouRecordingServer(t, `{"errcode":70001,"message":"app not exist"}`)
+
+	_, err := sms.QueryStatus(context.Background(), "rrid-1")
+
+	var smsErr *SmsError
+	if !errors.As(err, &smsErr) || smsErr.Provider != "yegou" || smsErr.Code != "70001" || !errors.Is(err, ErrAuth) {
+		t.Fatalf("QueryStatus: got %#v", err)
+	}
+
+	balance, err := sms.Balance(context.Background())
+	if !errors.As(err, &smsErr) || smsErr.Code != "70001" || !errors.Is(err, ErrAuth) {
+		t.Fatalf("Balance: got %#v", err)
+	}
+	if balance == nil || balance.Sms != 0 {
+		t.Fatalf("Balance: got %#v", balance)
+	}
+}
@@ -0,0 +1,232 @@
+package gsms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func newYeGouTestServer(t *testing.T, body string) YeGouSmsProvider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	sms := NewYeGouSms("app", "secret")
+	sms.SetGeteway(server.URL + "/")
+	sms.SetTemplateCode("100")
+
+	return sms
+}
+
+func TestYeGouErrorCodes(t *testing.T) {
+	cases := map[string]error{
+		`{"errcode":70001,"message":"app not exist"}`:  ErrAuth,
+		`{"errcode":70031,"message":"too frequently"}`: ErrRateLimited,
+		`{"errcode":70041,"message":"balance is low"}`: ErrInsufficientBalance,
+		`{"errcode":70022,"message":"not approved"}`:   ErrTemplateNotApproved,
+		`{"errcode":70023,"message":"params invalid"}`: ErrTemplateParamInvalid,
+		`{"errcode":12345,"message":"something else"}`: ErrProvider,
+	}
+
+	for body, want := range cases {
+		sms := newYeGouTestServer(t, body)
+
+		result, err := sms.SendMessage(context.Background(), NewMessage("13800000000"))
+		if !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", body, err, want)
+		}
+		if result == nil || result.IsSuccess {
+			t.Errorf("%s: got result %#v", body, result)
+		}
+	}
+}
+
+func TestYeGouSendValidationReturnsResult(t *testing.T) {
+	sms := NewYeGouSms("app", "secret")
+
+	message := NewMessage("13800000000")
+	message.OutId = "out-1"
+	result, err := sms.SendMessage(context.Background(), message)
+	if !errors.Is(err, ErrTemplateMissing) {
+		t.Fatalf("got %v, want ErrTemplateMissing", err)
+	}
+	if result == nil || result.OutId != "out-1" {
+		t.Fatalf("got result %#v", result)
+	}
+
+	message.TemplateCode = "100"
+	message.RequiredParams = []string{"code"}
+	result, err = sms.SendMessage(context.Background(), message)
+	if !errors.Is(err, ErrTemplateParamInvalid) {
+		t.Fatalf("got %v, want ErrTemplateParamInvalid", err)
+	}
+	if result == nil {
+		t.Fatal("result should not be nil")
+	}
+}
+
+func TestYeGouCheckCodeStatus(t *testing.T) {
+	cases := []struct {
+		body   string
+		status CodeVerifyStatus
+		err    error
+	}{
+		{`{"status":"ok"}`, CodeVerified, nil},
+		{`{"errcode":70053,"message":"request error"}`, CodeMismatch, nil},
+		{`{"errcode":70054,"message":"request error"}`, CodeExpired, nil},
+		{`{"errcode":70041,"message":"balance is not enough"}`, 0, ErrInsufficientBalance},
+		{`{"errcode":79990,"message":"验证码已过期"}`, 0, ErrProvider},
+	}
+
+	for _, c := range cases {
+		sms := newYeGouTestServer(t, c.body)
+
+		result, err := sms.CheckCode(context.Background(), "13800000000", "1234")
+		if (c.err == nil && err != nil) || (c.err != nil && !errors.Is(err, c.err)) {
+			t.Errorf("%s: got error %v, want %v", c.body, err, c.err)
+		}
+		if result == nil || result.Status != c.status {
+			t.Errorf("%s: got %#v, want status %v", c.body, result, c.status)
+		}
+
+		//未映射的错误码原样返回
+		var smsErr *SmsError
+		if c.err != nil && (!errors.As(err, &smsErr) || smsErr.Code != result.Code || len(smsErr.Code) == 0) {
+			t.Errorf("%s: got %#v", c.body, err)
+		}
+	}
+}
+
+func TestYeGouConcurrentSend(t *testing.T) {
+	sms := NewYeGouSms("app", "secret")
+
+	//野狗请求中没有签名和回传参数
+	testConcurrentSend(t, sms, "/", func(r *http.Request, body []byte) sentMessage {
+		form, _ := url.ParseQuery(string(body))
+		return sentMessage{
+			Mobile:       form.Get("mobile"),
+			TemplateCode: form.Get("templateId"),
+			Param:        templateParamFirst(form.Get("params")),
+		}
+	}, func(sent sentMessage) string {
+		return fmt.Sprintf(`{"status":"ok","data":{"rrid":"r-%s"}}`, sent.Mobile)
+	}, true, true)
+}
+
+// 野狗测试服务收到的请求
+type yegouTestRequest struct {
+	method string
+	path   string
+	params url.Values
+	count  int
+}
+
+// 记录最后一次请求的野狗测试服务，返回固定响应
+type yegouTestServer struct {
+	request yegouTestRequest
+	mu      sync.Mutex
+}
+
+func newYeGouRecordingServer(t *testing.T, body string, opts ...Option) (YeGouSmsProvider, *yegouTestServer) {
+	record := new(yegouTestServer)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		record.mu.Lock()
+		record.request = yegouTestRequest{method: r.Method, path: r.URL.Path, params: r.Form, count: record.request.count + 1}
+		record.mu.Unlock()
+
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	sms := NewYeGouSms("app", "secret", opts...)
+	sms.SetGeteway(server.URL + "/")
+
+	return sms, record
+}
+
+func (s *yegouTestServer) last() yegouTestRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.request
+}
+
+// 请求参数的签名是否正确
+func (r yegouTestRequest) isSigned() bool {
+	params := make(map[string]string, 0)
+	for key := range r.params {
+		if key != "signature" {
+			params[key] = r.params.Get(key)
+		}
+	}
+
+	return r.params.Get("signature") == yegouSign(params, "secret")
+}
+
+func TestYeGouNotifySend(t *testing.T) {
+	sms, server := newYeGouRecordingServer(t, `{"status":"ok","data":{"rrid":"rrid-1"}}`, WithYeGouSendType(YeGouSendTypeNotify))
+
+	message := NewMessage("13800000000", "13900000000")
+	message.TemplateCode = "100"
+	message.TemplateParam = NewSmsTemplateParams("name", "Tom", "date", "1月1日")
+	result, err := sms.SendMessage(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := server.last()
+	if record.method != http.MethodPost || record.path != "/app/notify/send" || !record.isSigned() {
+		t.Fatalf("got %s %s %v", record.method, record.path, record.params)
+	}
+
+	if record.params.Get("mobiles") != `["13800000000","13900000000"]` || len(record.params.Get("mobile")) > 0 ||
+		record.params.Get("templateId") != "100" || record.params.Get("params") != `["Tom","1月1日"]` {
+		t.Fatalf("got params %v", record.params)
+	}
+
+	if !result.IsSuccess || result.RequestId != "rrid-1" {
+		t.Fatalf("got %#v", result)
+	}
+}
+
+func TestYeGouCodeSend(t *testing.T) {
+	sms, server := newYeGouRecordingServer(t, `{"status":"ok","data":{"rrid":"rrid-2"}}`)
+	sms.SetTemplateCode("100")
+	sms.SetTemplateString("1234")
+
+	result, err := sms.SendMessage(context.Background(), NewMessage("13800000000"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := server.last()
+	if record.path != "/app/code/send" || record.params.Get("mobile") != "13800000000" || len(record.params.Get("mobiles")) > 0 ||
+		record.params.Get("params") != `["1234"]` || !record.isSigned() {
+		t.Fatalf("got %s %v", record.path, record.params)
+	}
+
+	if !result.IsSuccess || result.RequestId != "rrid-2" {
+		t.Fatalf("got %#v", result)
+	}
+
+	//验证码类型不能发送给多个手机号，不发送请求
+	result, err = sms.SendMessage(context.Background(), NewMessage("13800000000", "13900000000"))
+	if !errors.Is(err, ErrInvalidMobile) || result == nil || result.IsSuccess {
+		t.Fatalf("got %v %#v", err, result)
+	}
+	if count := server.last().count; count != 1 {
+		t.Fatalf("got %d requests, want 1", count)
+	}
+}
+
+func TestYeGouCancelSend(t *testing.T) {
+	testCancelSend(t, NewYeGouSms("app", "secret"), "/")
+}